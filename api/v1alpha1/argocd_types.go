@@ -1,29 +1,215 @@
 package v1alpha1
 
 import (
+	corev1 "k8s.io/api/core/v1"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
 	addonv1alpha1 "sigs.k8s.io/kubebuilder-declarative-pattern/pkg/patterns/addon/pkg/apis/v1alpha1"
 )
 
 // EDIT THIS FILE!  THIS IS SCAFFOLDING FOR YOU TO OWN!
 // NOTE: json tags are required.  Any new fields you add must have json tags for the fields to be serialized.
 
+// ArgoCDMode selects how ArgoCD is installed.
+// +kubebuilder:validation:Enum=HA;Standard
+type ArgoCDMode string
+
+const (
+	// ArgoCDModeHA installs ArgoCD with a redis-ha cluster and replicated API and repo servers.
+	ArgoCDModeHA ArgoCDMode = "HA"
+	// ArgoCDModeStandard installs ArgoCD with a single redis and single replicas of every component.
+	ArgoCDModeStandard ArgoCDMode = "Standard"
+)
+
 // ArgoCDSpec defines the desired state of ArgoCD
 type ArgoCDSpec struct {
 	addonv1alpha1.CommonSpec `json:",inline"`
 	addonv1alpha1.PatchSpec  `json:",inline"`
 
+	// Mode selects between the HA and the Standard (non-HA) manifest of the same version.
+	// +kubebuilder:default=HA
+	// +optional
+	Mode ArgoCDMode `json:"mode,omitempty"`
+
+	// Components toggles the optional components of ArgoCD.
+	// +optional
+	Components ArgoCDComponents `json:"components,omitempty"`
+
+	// Settings are rendered into the argocd-cm ConfigMap.
+	// +optional
+	Settings ArgoCDSettings `json:"settings,omitempty"`
+
+	// RBAC is rendered into the argocd-rbac-cm ConfigMap.
+	// +optional
+	RBAC ArgoCDRBAC `json:"rbac,omitempty"`
+
+	// Repositories are registered with ArgoCD as repository Secrets in the argocd namespace.
+	// +optional
+	Repositories []ArgoCDRepository `json:"repositories,omitempty"`
+
+	// RepositoryCredentialTemplates are registered with ArgoCD as repo-creds Secrets in the argocd namespace.
+	// Their credentials are used by every repository whose URL starts with the template URL.
+	// +optional
+	RepositoryCredentialTemplates []ArgoCDRepository `json:"repositoryCredentialTemplates,omitempty"`
+
+	// Server configures how argocd-server is reached.
+	// +optional
+	Server ArgoCDServer `json:"server,omitempty"`
+
+	// Bootstrap holds the AppProjects and Applications created once ArgoCD is running.
+	// +optional
+	Bootstrap ArgoCDBootstrap `json:"bootstrap,omitempty"`
+
+	// Plugins are config management plugins run as sidecars of argocd-repo-server.
+	// +optional
+	Plugins []ArgoCDPlugin `json:"plugins,omitempty"`
+
+	// UpgradePolicy controls when a new version of spec.channel is applied.
+	// +optional
+	UpgradePolicy *UpgradePolicy `json:"upgradePolicy,omitempty"`
+
 	// INSERT ADDITIONAL SPEC FIELDS - desired state of cluster
 	// Important: Run "make" to regenerate code after modifying this file
 }
 
+// ArgoCDComponents configures the components of ArgoCD.
+type ArgoCDComponents struct {
+	// Server configures argocd-server.
+	// +optional
+	Server *WorkloadSpec `json:"server,omitempty"`
+
+	// RepoServer configures argocd-repo-server.
+	// +optional
+	RepoServer *WorkloadSpec `json:"repoServer,omitempty"`
+
+	// ApplicationController configures argocd-application-controller.
+	// +optional
+	ApplicationController *WorkloadSpec `json:"applicationController,omitempty"`
+
+	// Dex configures argocd-dex-server. Disable it when using an external OIDC provider.
+	// +optional
+	Dex *ArgoCDOptionalComponent `json:"dex,omitempty"`
+
+	// Notifications configures argocd-notifications-controller.
+	// +optional
+	Notifications *ArgoCDOptionalComponent `json:"notifications,omitempty"`
+
+	// ApplicationSet configures argocd-applicationset-controller.
+	// The ApplicationSet CRD is kept when the controller is disabled.
+	// +optional
+	ApplicationSet *ArgoCDOptionalComponent `json:"applicationSet,omitempty"`
+}
+
+// ArgoCDOptionalComponent configures an ArgoCD component that can be turned off.
+type ArgoCDOptionalComponent struct {
+	// Enabled installs the component. Defaults to true.
+	// +optional
+	Enabled *bool `json:"enabled,omitempty"`
+
+	WorkloadSpec `json:",inline"`
+}
+
+// IsEnabled reports whether the component should be installed. Unset components are enabled.
+func (c *ArgoCDOptionalComponent) IsEnabled() bool {
+	return c == nil || c.Enabled == nil || *c.Enabled
+}
+
+// Workload returns the workload settings of the component, nil if the component is unset.
+func (c *ArgoCDOptionalComponent) Workload() *WorkloadSpec {
+	if c == nil {
+		return nil
+	}
+	return &c.WorkloadSpec
+}
+
+// ArgoCDSettings configures ArgoCD through the argocd-cm ConfigMap.
+type ArgoCDSettings struct {
+	// URL is the external URL of ArgoCD, required for SSO (`url`).
+	// +optional
+	URL string `json:"url,omitempty"`
+
+	// OIDCConfig is the YAML configuration of an external OIDC provider (`oidc.config`).
+	// +optional
+	OIDCConfig string `json:"oidcConfig,omitempty"`
+
+	// ResourceCustomizations is the YAML map of health checks and actions per resource kind (`resource.customizations`).
+	// +optional
+	ResourceCustomizations string `json:"resourceCustomizations,omitempty"`
+
+	// ResourceExclusions is the YAML list of resources ArgoCD does not watch (`resource.exclusions`).
+	// +optional
+	ResourceExclusions string `json:"resourceExclusions,omitempty"`
+
+	// ReconciliationTimeout is the interval at which applications are refreshed (`timeout.reconciliation`).
+	// +optional
+	ReconciliationTimeout *metav1.Duration `json:"reconciliationTimeout,omitempty"`
+}
+
+// ArgoCDRBAC configures the ArgoCD RBAC policy through the argocd-rbac-cm ConfigMap.
+type ArgoCDRBAC struct {
+	// PolicyCSV holds the policy and group rules in CSV format (`policy.csv`).
+	// +optional
+	PolicyCSV string `json:"policyCSV,omitempty"`
+
+	// DefaultPolicy is the role granted to authenticated users without a matching rule, e.g. role:readonly (`policy.default`).
+	// +optional
+	DefaultPolicy string `json:"defaultPolicy,omitempty"`
+
+	// Scopes are the OIDC claims used to match groups in the policy, defaults to [groups] (`scopes`).
+	// +optional
+	Scopes []string `json:"scopes,omitempty"`
+}
+
+// ArgoCDRepository describes a repository, or a credential template, and where to read its credentials.
+type ArgoCDRepository struct {
+	// Name of the Secret generated in the argocd namespace.
+	Name string `json:"name"`
+
+	// URL of the repository, or the URL prefix matched by a credential template.
+	URL string `json:"url"`
+
+	// Type of the repository.
+	// +kubebuilder:validation:Enum=git;helm
+	// +kubebuilder:default=git
+	// +optional
+	Type string `json:"type,omitempty"`
+
+	// Username for HTTPS authentication.
+	// +optional
+	Username *SecretKeyReference `json:"username,omitempty"`
+
+	// Password or token for HTTPS authentication.
+	// +optional
+	Password *SecretKeyReference `json:"password,omitempty"`
+
+	// SSHPrivateKey for SSH authentication.
+	// +optional
+	SSHPrivateKey *SecretKeyReference `json:"sshPrivateKey,omitempty"`
+}
+
 // ArgoCDStatus defines the observed state of ArgoCD
 type ArgoCDStatus struct {
 	addonv1alpha1.CommonStatus     `json:",inline"`
 	addonv1alpha1.StatusConditions `json:",inline"`
+	DeployedPackage                `json:",inline"`
+	RollbackStatus                 `json:",inline"`
 
-	// INSERT ADDITIONAL STATUS FIELD - define observed state of cluster
-	// Important: Run "make" to regenerate code after modifying this file
+	// AvailableVersion is the newer version of the channel waiting for the upgrade policy to be applied.
+	// +optional
+	AvailableVersion string `json:"availableVersion,omitempty"`
+
+	// ExternalURL is the URL argocd-server is exposed at, empty while it is only reachable inside the cluster.
+	// It is not reported for a NodePort Service, as the addresses of the nodes are not known.
+	// +optional
+	ExternalURL string `json:"externalURL,omitempty"`
+
+	// Inventory summarizes the objects applied by the last successful reconcile.
+	// +optional
+	Inventory *Inventory `json:"inventory,omitempty"`
+
+	// Components are the Deployments and StatefulSets of the package.
+	// +optional
+	Components []ComponentStatus `json:"components,omitempty"`
 }
 
 //+kubebuilder:object:root=true
@@ -62,6 +248,18 @@ func (o *ArgoCD) SetCommonStatus(s addonv1alpha1.CommonStatus) {
 	o.Status.CommonStatus = s
 }
 
+func (o *ArgoCD) GetUpgradePolicy() *UpgradePolicy {
+	return o.Spec.UpgradePolicy
+}
+
+func (o *ArgoCD) GetDeployedPackage() DeployedPackage {
+	return o.Status.DeployedPackage
+}
+
+func (o *ArgoCD) GetRollbackStatus() RollbackStatus {
+	return o.Status.RollbackStatus
+}
+
 //+kubebuilder:object:root=true
 
 // ArgoCDList contains a list of ArgoCD
@@ -74,3 +272,186 @@ type ArgoCDList struct {
 func init() {
 	SchemeBuilder.Register(&ArgoCD{}, &ArgoCDList{})
 }
+
+// ArgoCDServer configures argocd-server beyond its workload settings.
+type ArgoCDServer struct {
+	// Expose makes argocd-server reachable from outside the cluster.
+	// +optional
+	Expose *ArgoCDServerExpose `json:"expose,omitempty"`
+}
+
+// ArgoCDServerExpose selects how argocd-server is exposed. At most one of Ingress and HTTPRoute may be set.
+// With either of them TLS is terminated in front of argocd-server, which then serves plain HTTP.
+type ArgoCDServerExpose struct {
+	// ServiceType of the argocd-server Service.
+	// +kubebuilder:validation:Enum=ClusterIP;LoadBalancer;NodePort
+	// +kubebuilder:default=ClusterIP
+	// +optional
+	ServiceType corev1.ServiceType `json:"serviceType,omitempty"`
+
+	// Ingress generates a networking.k8s.io Ingress routing to argocd-server.
+	// +optional
+	Ingress *ArgoCDServerIngress `json:"ingress,omitempty"`
+
+	// HTTPRoute generates a Gateway API HTTPRoute routing to argocd-server.
+	// +optional
+	HTTPRoute *ArgoCDServerHTTPRoute `json:"httpRoute,omitempty"`
+}
+
+// ArgoCDServerIngress configures the Ingress of argocd-server.
+type ArgoCDServerIngress struct {
+	// Hostname argocd-server is served at.
+	Hostname string `json:"hostname"`
+
+	// IngressClassName of the Ingress, the cluster default class if unset.
+	// +optional
+	IngressClassName *string `json:"ingressClassName,omitempty"`
+
+	// Annotations of the Ingress, e.g. to configure the ingress controller.
+	// +optional
+	Annotations map[string]string `json:"annotations,omitempty"`
+
+	// TLSSecretName is the name of the Secret in the argocd namespace holding the TLS certificate of Hostname.
+	// The Ingress serves plain HTTP if unset.
+	// +optional
+	TLSSecretName string `json:"tlsSecretName,omitempty"`
+}
+
+// ArgoCDServerHTTPRoute configures the HTTPRoute of argocd-server.
+type ArgoCDServerHTTPRoute struct {
+	// Hostname argocd-server is served at.
+	Hostname string `json:"hostname"`
+
+	// ParentRefs are the Gateways the HTTPRoute attaches to.
+	// The TLS certificate of Hostname is referenced by the Gateway listeners.
+	// +kubebuilder:validation:MinItems=1
+	ParentRefs []GatewayReference `json:"parentRefs"`
+}
+
+// GatewayReference selects a Gateway, or one of its listeners, in any namespace.
+type GatewayReference struct {
+	// Namespace of the Gateway.
+	Namespace string `json:"namespace"`
+
+	// Name of the Gateway.
+	Name string `json:"name"`
+
+	// SectionName is the name of the Gateway listener, all listeners if unset.
+	// +optional
+	SectionName string `json:"sectionName,omitempty"`
+}
+
+// ArgoCDBootstrap holds the AppProjects and Applications managed alongside ArgoCD, e.g. a root project
+// and an app-of-apps Application. They are applied once the argocd CRDs are established and the
+// application controller is healthy, and are deleted when removed from the list.
+type ArgoCDBootstrap struct {
+	// Projects are created as AppProjects in the argocd namespace.
+	// +optional
+	Projects []ArgoCDBootstrapObject `json:"projects,omitempty"`
+
+	// Applications are created as Applications in the argocd namespace.
+	// +optional
+	Applications []ArgoCDBootstrapObject `json:"applications,omitempty"`
+}
+
+// ArgoCDBootstrapObject is an AppProject or Application created in the argocd namespace.
+type ArgoCDBootstrapObject struct {
+	// Name of the object.
+	Name string `json:"name"`
+
+	// Labels of the object.
+	// +optional
+	Labels map[string]string `json:"labels,omitempty"`
+
+	// Annotations of the object.
+	// +optional
+	Annotations map[string]string `json:"annotations,omitempty"`
+
+	// Finalizers of the object, e.g. resources-finalizer.argocd.argoproj.io to delete the resources of an
+	// Application together with it.
+	// +optional
+	Finalizers []string `json:"finalizers,omitempty"`
+
+	// Spec of the AppProject or Application, as documented by ArgoCD.
+	// +kubebuilder:pruning:PreserveUnknownFields
+	Spec runtime.RawExtension `json:"spec"`
+}
+
+// ArgoCDPlugin is a config management plugin (CMP). It runs as a sidecar of argocd-repo-server, configured
+// by a plugin.yaml generated from the fields below.
+type ArgoCDPlugin struct {
+	// Name of the plugin. Applications select the plugin by <name>-<version>, or <name> if Version is unset.
+	// +kubebuilder:validation:MaxLength=59
+	Name string `json:"name"`
+
+	// Version of the plugin.
+	// +optional
+	Version string `json:"version,omitempty"`
+
+	// Image of the sidecar, holding the tools run by the plugin commands.
+	Image string `json:"image"`
+
+	// Init is run in the application source directory before Generate.
+	// +optional
+	Init *ArgoCDPluginCommand `json:"init,omitempty"`
+
+	// Generate prints the manifests of the application to stdout.
+	Generate ArgoCDPluginCommand `json:"generate"`
+
+	// Discover selects the applications the plugin is used for when they do not name a plugin.
+	// +optional
+	Discover *ArgoCDPluginDiscovery `json:"discover,omitempty"`
+
+	// Env of the sidecar.
+	// +optional
+	Env []corev1.EnvVar `json:"env,omitempty"`
+
+	// Volumes are added to the argocd-repo-server pods.
+	// +optional
+	Volumes []corev1.Volume `json:"volumes,omitempty"`
+
+	// VolumeMounts of the sidecar, referencing Volumes or the volumes of argocd-repo-server.
+	// +optional
+	VolumeMounts []corev1.VolumeMount `json:"volumeMounts,omitempty"`
+
+	// Resources of the sidecar.
+	// +optional
+	Resources *corev1.ResourceRequirements `json:"resources,omitempty"`
+}
+
+// ArgoCDPluginCommand is a command run by a config management plugin.
+type ArgoCDPluginCommand struct {
+	// Command is the executable and its leading arguments.
+	// +kubebuilder:validation:MinItems=1
+	Command []string `json:"command"`
+
+	// Args are appended to Command.
+	// +optional
+	Args []string `json:"args,omitempty"`
+}
+
+// ArgoCDPluginDiscovery selects applications by their source files. Exactly one of FileName and Find must be set.
+type ArgoCDPluginDiscovery struct {
+	// FileName is a glob matched against the files of the application source directory.
+	// +optional
+	FileName string `json:"fileName,omitempty"`
+
+	// Find matches the applications for which the command prints output, or which contain files matching the glob.
+	// +optional
+	Find *ArgoCDPluginFind `json:"find,omitempty"`
+}
+
+// ArgoCDPluginFind is a discovery rule of a config management plugin. Exactly one of Command and Glob must be set.
+type ArgoCDPluginFind struct {
+	// Command is run in the application source directory; the plugin is used when it prints output.
+	// +optional
+	Command []string `json:"command,omitempty"`
+
+	// Args are appended to Command.
+	// +optional
+	Args []string `json:"args,omitempty"`
+
+	// Glob is matched recursively against the files of the application source directory.
+	// +optional
+	Glob string `json:"glob,omitempty"`
+}
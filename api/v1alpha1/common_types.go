@@ -0,0 +1,215 @@
+package v1alpha1
+
+import (
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// WorkloadSpec configures the Deployment or StatefulSet of an addon component.
+// Each field that is set replaces the value shipped in the package manifest.
+type WorkloadSpec struct {
+	// Replicas is the number of pods of the component.
+	// +kubebuilder:validation:Minimum=0
+	// +optional
+	Replicas *int32 `json:"replicas,omitempty"`
+
+	// Resources are the compute resources of the component's main container.
+	// +optional
+	Resources *corev1.ResourceRequirements `json:"resources,omitempty"`
+
+	// NodeSelector constrains the component's pods to nodes with matching labels.
+	// +optional
+	NodeSelector map[string]string `json:"nodeSelector,omitempty"`
+
+	// Tolerations of the component's pods.
+	// +optional
+	Tolerations []corev1.Toleration `json:"tolerations,omitempty"`
+
+	// Affinity of the component's pods.
+	// +optional
+	Affinity *corev1.Affinity `json:"affinity,omitempty"`
+
+	// PriorityClassName of the component's pods.
+	// +optional
+	PriorityClassName string `json:"priorityClassName,omitempty"`
+}
+
+// SecretKeyReference selects a key of a Secret in any namespace.
+type SecretKeyReference struct {
+	// Namespace of the Secret.
+	Namespace string `json:"namespace"`
+
+	// Name of the Secret.
+	Name string `json:"name"`
+
+	// Key of the value in the Secret data.
+	Key string `json:"key"`
+}
+
+// ObjectStatus is the status of an applied object, computed with kstatus.
+type ObjectStatus struct {
+	// Group of the object, empty for the core group.
+	// +optional
+	Group string `json:"group,omitempty"`
+	Kind  string `json:"kind"`
+	// Namespace of the object, empty for cluster scoped objects.
+	// +optional
+	Namespace string `json:"namespace,omitempty"`
+	Name      string `json:"name"`
+
+	// Status is the kstatus of the object, e.g. InProgress or Failed.
+	Status string `json:"status"`
+
+	// Message tells why the object has not reached its desired state.
+	// +optional
+	Message string `json:"message,omitempty"`
+}
+
+// ComponentStatus is the status of a Deployment or StatefulSet of an addon.
+type ComponentStatus struct {
+	// Kind is Deployment or StatefulSet.
+	Kind string `json:"kind"`
+	Name string `json:"name"`
+
+	// DesiredReplicas is the number of pods in the spec of the workload.
+	DesiredReplicas int32 `json:"desiredReplicas"`
+	// ReadyReplicas is the number of ready pods.
+	ReadyReplicas int32 `json:"readyReplicas"`
+
+	// Image of the container named after the workload, or of the first container of the pods if none is.
+	// +optional
+	Image string `json:"image,omitempty"`
+
+	// Status is the kstatus of the workload, e.g. Current or InProgress.
+	Status string `json:"status"`
+	// Message tells why the workload has not reached its desired state.
+	// +optional
+	Message string `json:"message,omitempty"`
+}
+
+// DeployedPackage identifies the package last applied successfully.
+type DeployedPackage struct {
+	// ObservedVersion is the version of the package.
+	// +optional
+	ObservedVersion string `json:"observedVersion,omitempty"`
+
+	// ResolvedChannel is the channel the version was resolved from, empty when spec.version is set.
+	// +optional
+	ResolvedChannel string `json:"resolvedChannel,omitempty"`
+
+	// ManifestDigest is the sha256 digest of the package manifest.
+	// +optional
+	ManifestDigest string `json:"manifestDigest,omitempty"`
+
+	// ObservedGeneration is the generation of the spec the package was applied for.
+	// +optional
+	ObservedGeneration int64 `json:"observedGeneration,omitempty"`
+}
+
+// UpgradeMode selects whether an addon following a channel upgrades to its new versions.
+// +kubebuilder:validation:Enum=Auto;Manual
+type UpgradeMode string
+
+const (
+	// UpgradeModeAuto applies the new versions of the channel in the maintenance window.
+	UpgradeModeAuto UpgradeMode = "Auto"
+	// UpgradeModeManual keeps the deployed version until spec.version or spec.channel changes.
+	UpgradeModeManual UpgradeMode = "Manual"
+)
+
+// UpgradePolicy controls when an addon following a channel upgrades to a newer version of the channel,
+// and when an upgrade is rolled back.
+type UpgradePolicy struct {
+	// Mode selects whether new versions of the channel are applied automatically. Mode,
+	// MaintenanceWindow and MinSoakTime do not apply to addons with spec.version set.
+	// +kubebuilder:default=Auto
+	// +optional
+	Mode UpgradeMode `json:"mode,omitempty"`
+
+	// MaintenanceWindow restricts automatic upgrades to a recurring window. Upgrades are applied as soon
+	// as they are available if unset.
+	// +optional
+	MaintenanceWindow *MaintenanceWindow `json:"maintenanceWindow,omitempty"`
+
+	// MinSoakTime is how long a version must have been promoted to the channel before it is applied,
+	// e.g. 168h.
+	// +optional
+	MinSoakTime *metav1.Duration `json:"minSoakTime,omitempty"`
+
+	// ProgressDeadline is how long a new version, from the channel or spec.version, has to become healthy
+	// before the last healthy version is applied again. Defaults to 10m, 0s disables rollbacks.
+	// +optional
+	ProgressDeadline *metav1.Duration `json:"progressDeadline,omitempty"`
+}
+
+// MaintenanceWindow is a recurring window.
+type MaintenanceWindow struct {
+	// Schedule is the cron expression of the start of the window, in UTC, e.g. "0 2 * * 6" for
+	// Saturdays at 2:00.
+	Schedule string `json:"schedule"`
+
+	// Duration of the window, e.g. 4h.
+	Duration metav1.Duration `json:"duration"`
+}
+
+// RollbackStatus tracks the health of the applied versions, to roll back the upgrades which do not
+// become healthy.
+type RollbackStatus struct {
+	// LastHealthyVersion is the last version which became healthy.
+	// +optional
+	LastHealthyVersion string `json:"lastHealthyVersion,omitempty"`
+
+	// ProgressingVersion is the version applied since LastHealthyVersion, until it becomes healthy.
+	// +optional
+	ProgressingVersion string `json:"progressingVersion,omitempty"`
+
+	// ProgressDeadline is when ProgressingVersion is rolled back to LastHealthyVersion unless it became
+	// healthy.
+	// +optional
+	ProgressDeadline *metav1.Time `json:"progressDeadline,omitempty"`
+
+	// RolledBackVersion is the version rolled back after it missed its progress deadline. It is not applied
+	// again until another version is requested.
+	// +optional
+	RolledBackVersion string `json:"rolledBackVersion,omitempty"`
+}
+
+// Inventory summarizes the objects applied for an addon.
+type Inventory struct {
+	// Count is the number of applied objects.
+	Count int `json:"count"`
+
+	// Kinds counts the applied objects of each kind.
+	// +optional
+	Kinds []InventoryKind `json:"kinds,omitempty"`
+
+	// Objects are the applied objects, in the order they were applied. The list is truncated for large
+	// packages.
+	// +optional
+	Objects []InventoryObject `json:"objects,omitempty"`
+
+	// Truncated is true when Objects does not list all the applied objects.
+	// +optional
+	Truncated bool `json:"truncated,omitempty"`
+}
+
+// InventoryKind is the number of applied objects of a kind.
+type InventoryKind struct {
+	// Group of the kind, empty for the core group.
+	// +optional
+	Group string `json:"group,omitempty"`
+	Kind  string `json:"kind"`
+	Count int    `json:"count"`
+}
+
+// InventoryObject is an applied object.
+type InventoryObject struct {
+	// Group of the object, empty for the core group.
+	// +optional
+	Group string `json:"group,omitempty"`
+	Kind  string `json:"kind"`
+	// Namespace of the object, empty for cluster scoped objects.
+	// +optional
+	Namespace string `json:"namespace,omitempty"`
+	Name      string `json:"name"`
+}
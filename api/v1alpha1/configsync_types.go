@@ -13,21 +13,208 @@ type ConfigSyncSpec struct {
 	addonv1alpha1.CommonSpec `json:",inline"`
 	addonv1alpha1.PatchSpec  `json:",inline"`
 
-	// INSERT ADDITIONAL SPEC FIELDS - desired state of cluster
-	// Important: Run "make" to regenerate code after modifying this file
+	// Operator configures the config-management-operator Deployment.
+	// +optional
+	Operator *WorkloadSpec `json:"operator,omitempty"`
+
+	// RootSync configures the RootSync syncing the cluster from a source of truth. It is created once the
+	// config-management-operator installed the RootSync CRD.
+	// +optional
+	RootSync *SyncSource `json:"rootSync,omitempty"`
+
+	// PolicyController configures the Policy Controller installed by the operator.
+	// +optional
+	PolicyController *PolicyController `json:"policyController,omitempty"`
+
+	// HydrationController configures the rendering of the source of truth of spec.rootSync. By default
+	// Config Sync detects whether the source of truth needs rendering.
+	// +optional
+	HydrationController *HydrationController `json:"hydrationController,omitempty"`
+
+	// PreventDrift enables the Config Sync admission webhook, rejecting changes to the synced objects
+	// which are not made through the source of truth.
+	// +optional
+	PreventDrift bool `json:"preventDrift,omitempty"`
+
+	// UpgradePolicy controls when a new version of spec.channel is applied.
+	// +optional
+	UpgradePolicy *UpgradePolicy `json:"upgradePolicy,omitempty"`
+}
+
+// PolicyController configures the Policy Controller.
+type PolicyController struct {
+	// Enabled installs the Policy Controller.
+	Enabled bool `json:"enabled"`
+
+	// TemplateLibraryInstalled installs the default ConstraintTemplates.
+	// +kubebuilder:default=true
+	// +optional
+	TemplateLibraryInstalled *bool `json:"templateLibraryInstalled,omitempty"`
+
+	// ReferentialRulesEnabled allows `data.inventory` references in the Rego of the ConstraintTemplates.
+	// +optional
+	ReferentialRulesEnabled bool `json:"referentialRulesEnabled,omitempty"`
+
+	// LogDeniesEnabled logs all denies and dry run failures.
+	// +optional
+	LogDeniesEnabled bool `json:"logDeniesEnabled,omitempty"`
+
+	// MutationEnabled installs the mutation CRDs, webhook and controller.
+	// +optional
+	MutationEnabled bool `json:"mutationEnabled,omitempty"`
+
+	// ExemptableNamespaces can be exempted from the Policy Controller with the
+	// admission.gatekeeper.sh/ignore label.
+	// +optional
+	ExemptableNamespaces []string `json:"exemptableNamespaces,omitempty"`
+
+	// AuditIntervalSeconds is the number of seconds between audit runs, 0 disables the audit.
+	// +kubebuilder:validation:Minimum=0
+	// +optional
+	AuditIntervalSeconds *int64 `json:"auditIntervalSeconds,omitempty"`
+}
+
+// HydrationController configures the hydration-controller rendering the source of truth.
+type HydrationController struct {
+	// Enabled runs the hydration-controller in the reconciler of spec.rootSync, so that the kustomizations
+	// and Helm charts of its source of truth are rendered.
+	Enabled bool `json:"enabled"`
 }
 
 // ConfigSyncStatus defines the observed state of ConfigSync
 type ConfigSyncStatus struct {
 	addonv1alpha1.CommonStatus `json:",inline"`
+	// Conditions are Ready, Syncing and Stalled. Ready is true once Config Sync is installed and every
+	// RootSync and RepoSync synced its latest commit without errors.
+	addonv1alpha1.StatusConditions `json:",inline"`
+	DeployedPackage                `json:",inline"`
+	RollbackStatus                 `json:",inline"`
+
+	// AvailableVersion is the newer version of the channel waiting for the upgrade policy to be applied.
+	// +optional
+	AvailableVersion string `json:"availableVersion,omitempty"`
+
+	// BlockedBy is the first applied object which has not reached its desired state, blocking readiness.
+	// +optional
+	BlockedBy *ObjectStatus `json:"blockedBy,omitempty"`
+
+	// Inventory summarizes the objects applied by the last successful reconcile.
+	// +optional
+	Inventory *Inventory `json:"inventory,omitempty"`
 
-	// INSERT ADDITIONAL STATUS FIELD - define observed state of cluster
-	// Important: Run "make" to regenerate code after modifying this file
+	// Syncs are the RootSyncs and RepoSyncs of the cluster.
+	// +optional
+	Syncs []SyncSummary `json:"syncs,omitempty"`
+
+	// ErrorCount is the number of errors reported by all the RootSyncs and RepoSyncs.
+	// +optional
+	ErrorCount int `json:"errorCount,omitempty"`
+}
+
+// SyncSummary summarizes the status of a RootSync or RepoSync.
+type SyncSummary struct {
+	// Kind is RootSync or RepoSync.
+	Kind      string `json:"kind"`
+	Namespace string `json:"namespace"`
+	Name      string `json:"name"`
+
+	// SourceCommit is the commit, or the image digest or chart version, last fetched.
+	// +optional
+	SourceCommit string `json:"sourceCommit,omitempty"`
+	// RenderingCommit is the commit last rendered.
+	// +optional
+	RenderingCommit string `json:"renderingCommit,omitempty"`
+	// SyncCommit is the commit last applied to the cluster.
+	// +optional
+	SyncCommit string `json:"syncCommit,omitempty"`
+
+	// Syncing is true while the last fetched commit is not synced yet.
+	// +optional
+	Syncing bool `json:"syncing,omitempty"`
+	// Stalled is true when the reconciler of the RootSync or RepoSync cannot make progress.
+	// +optional
+	Stalled bool `json:"stalled,omitempty"`
+
+	// SourceErrorCount is the number of errors fetching the source of truth.
+	// +optional
+	SourceErrorCount int `json:"sourceErrorCount,omitempty"`
+	// RenderingErrorCount is the number of errors rendering the source of truth.
+	// +optional
+	RenderingErrorCount int `json:"renderingErrorCount,omitempty"`
+	// SyncErrorCount is the number of errors applying the source of truth to the cluster.
+	// +optional
+	SyncErrorCount int `json:"syncErrorCount,omitempty"`
+}
+
+// SourceType is the kind of source of truth synced by Config Sync.
+// +kubebuilder:validation:Enum=git;oci;helm
+type SourceType string
+
+const (
+	SourceTypeGit  SourceType = "git"
+	SourceTypeOCI  SourceType = "oci"
+	SourceTypeHelm SourceType = "helm"
+)
+
+// SyncSource configures the source of truth of a RootSync or RepoSync.
+type SyncSource struct {
+	// SourceType of the source of truth.
+	// +kubebuilder:default=git
+	// +optional
+	SourceType SourceType `json:"sourceType,omitempty"`
+
+	// SourceFormat of the git repository or OCI image, hierarchy or unstructured.
+	// RepoSyncs only support unstructured.
+	// +kubebuilder:validation:Enum=hierarchy;unstructured
+	// +optional
+	SourceFormat string `json:"sourceFormat,omitempty"`
+
+	// Repo is the URL of the git repository, the OCI image without tag, or the URL of the helm repository.
+	Repo string `json:"repo"`
+
+	// Branch of the git repository.
+	// +optional
+	Branch string `json:"branch,omitempty"`
+
+	// Revision is the git tag or commit, the tag or digest of the OCI image, or the version of the helm chart.
+	// +optional
+	Revision string `json:"revision,omitempty"`
+
+	// Dir is the directory of the configs in the git repository or OCI image.
+	// +optional
+	Dir string `json:"dir,omitempty"`
+
+	// Chart is the name of the helm chart.
+	// +optional
+	Chart string `json:"chart,omitempty"`
+
+	// Auth is the type of the credentials used to read the source of truth.
+	// +kubebuilder:validation:Enum=none;ssh;cookiefile;gcenode;token;gcpserviceaccount;k8sserviceaccount
+	// +kubebuilder:default=none
+	// +optional
+	Auth string `json:"auth,omitempty"`
+
+	// SecretRef is the name of the Secret in the config-management-system namespace holding the credentials
+	// of the ssh, cookiefile and token auth types.
+	// +optional
+	SecretRef string `json:"secretRef,omitempty"`
+
+	// GCPServiceAccountEmail is the service account impersonated by the gcpserviceaccount auth type.
+	// +optional
+	GCPServiceAccountEmail string `json:"gcpServiceAccountEmail,omitempty"`
+
+	// Period between two syncs of the source of truth.
+	// +optional
+	Period *metav1.Duration `json:"period,omitempty"`
 }
 
 //+kubebuilder:object:root=true
 //+kubebuilder:subresource:status
 //+kubebuilder:resource:scope=Cluster
+//+kubebuilder:printcolumn:name="Ready",type=string,JSONPath=`.status.conditions[?(@.type=="Ready")].status`
+//+kubebuilder:printcolumn:name="Syncing",type=string,JSONPath=`.status.conditions[?(@.type=="Syncing")].status`
+//+kubebuilder:printcolumn:name="Stalled",type=string,JSONPath=`.status.conditions[?(@.type=="Stalled")].status`
+//+kubebuilder:printcolumn:name="Errors",type=integer,JSONPath=`.status.errorCount`
 
 // ConfigSync is the Schema for the configsyncs API
 type ConfigSync struct {
@@ -60,6 +247,18 @@ func (o *ConfigSync) SetCommonStatus(s addonv1alpha1.CommonStatus) {
 	o.Status.CommonStatus = s
 }
 
+func (o *ConfigSync) GetUpgradePolicy() *UpgradePolicy {
+	return o.Spec.UpgradePolicy
+}
+
+func (o *ConfigSync) GetDeployedPackage() DeployedPackage {
+	return o.Status.DeployedPackage
+}
+
+func (o *ConfigSync) GetRollbackStatus() RollbackStatus {
+	return o.Status.RollbackStatus
+}
+
 //+kubebuilder:object:root=true
 
 // ConfigSyncList contains a list of ConfigSync
@@ -0,0 +1,105 @@
+package v1alpha1
+
+import (
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	addonv1alpha1 "sigs.k8s.io/kubebuilder-declarative-pattern/pkg/patterns/addon/pkg/apis/v1alpha1"
+)
+
+// ConfigSyncTenantSpec defines the desired state of ConfigSyncTenant
+type ConfigSyncTenantSpec struct {
+	// ConfigSyncRef is the name of the cluster ConfigSync installing Config Sync.
+	ConfigSyncRef string `json:"configSyncRef"`
+
+	// RepoSync is the source of truth synced into the namespace of the ConfigSyncTenant.
+	RepoSync SyncSource `json:"repoSync"`
+
+	// ClusterRole is bound in the namespace to the reconciler of the RepoSync.
+	// +kubebuilder:default=admin
+	// +optional
+	ClusterRole string `json:"clusterRole,omitempty"`
+}
+
+// ConfigSyncTenantStatus defines the observed state of ConfigSyncTenant
+type ConfigSyncTenantStatus struct {
+	addonv1alpha1.CommonStatus     `json:",inline"`
+	addonv1alpha1.StatusConditions `json:",inline"`
+
+	// RepoSync is the status of the RepoSync.
+	// +optional
+	RepoSync SyncStatus `json:"repoSync,omitempty"`
+}
+
+// SyncStatus summarizes the status of a RootSync or RepoSync.
+type SyncStatus struct {
+	// Commit is the commit, or the image digest or chart version, last synced.
+	// +optional
+	Commit string `json:"commit,omitempty"`
+
+	// Errors are the errors reported while fetching, rendering or syncing the source of truth.
+	// +optional
+	Errors []SyncError `json:"errors,omitempty"`
+}
+
+// SyncError is an error reported by a RootSync or RepoSync.
+type SyncError struct {
+	// Phase the error was reported in, source, rendering or sync.
+	Phase string `json:"phase"`
+
+	// Code of the error, e.g. KNV1021.
+	// +optional
+	Code string `json:"code,omitempty"`
+
+	// Message of the error.
+	Message string `json:"message"`
+}
+
+//+kubebuilder:object:root=true
+//+kubebuilder:subresource:status
+//+kubebuilder:printcolumn:name="Healthy",type=boolean,JSONPath=`.status.healthy`
+//+kubebuilder:printcolumn:name="Commit",type=string,JSONPath=`.status.repoSync.commit`
+
+// ConfigSyncTenant is the Schema for the configsynctenants API. It syncs its namespace from a source of
+// truth with a RepoSync of the Config Sync installed by a ConfigSync.
+type ConfigSyncTenant struct {
+	metav1.TypeMeta   `json:",inline"`
+	metav1.ObjectMeta `json:"metadata,omitempty"`
+
+	Spec   ConfigSyncTenantSpec   `json:"spec,omitempty"`
+	Status ConfigSyncTenantStatus `json:"status,omitempty"`
+}
+
+var _ addonv1alpha1.CommonObject = &ConfigSyncTenant{}
+
+func (o *ConfigSyncTenant) ComponentName() string {
+	return "configsynctenant"
+}
+
+// CommonSpec is empty, the objects of a ConfigSyncTenant are not loaded from a package.
+func (o *ConfigSyncTenant) CommonSpec() addonv1alpha1.CommonSpec {
+	return addonv1alpha1.CommonSpec{}
+}
+
+func (o *ConfigSyncTenant) PatchSpec() addonv1alpha1.PatchSpec {
+	return addonv1alpha1.PatchSpec{}
+}
+
+func (o *ConfigSyncTenant) GetCommonStatus() addonv1alpha1.CommonStatus {
+	return o.Status.CommonStatus
+}
+
+func (o *ConfigSyncTenant) SetCommonStatus(s addonv1alpha1.CommonStatus) {
+	o.Status.CommonStatus = s
+}
+
+//+kubebuilder:object:root=true
+
+// ConfigSyncTenantList contains a list of ConfigSyncTenant
+type ConfigSyncTenantList struct {
+	metav1.TypeMeta `json:",inline"`
+	metav1.ListMeta `json:"metadata,omitempty"`
+	Items           []ConfigSyncTenant `json:"items"`
+}
+
+func init() {
+	SchemeBuilder.Register(&ConfigSyncTenant{}, &ConfigSyncTenantList{})
+}
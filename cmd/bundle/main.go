@@ -0,0 +1,68 @@
+// Command bundle writes the packages of a channel to a tarball for air-gapped clusters, e.g.
+//
+//	bundle --channel=./channels --channel-name=stable --package=argocd --version=2.5.11 --output=argocd.tar.gz
+//
+// The images the packages reference are printed, to be mirrored to the internal registry. The tarball is
+// extracted into the directory the manager reads the channels from with --channel=<dir>, which pulls the
+// images from --image-registry. The images of the addon specs, e.g. of the ArgoCD plugins, are not
+// bundled nor rewritten, and must be pulled from a registry reachable by the cluster. A signed channel
+// must be signed again once bundled to keep --channel-public-key.
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"os"
+	"strings"
+
+	addonloaders "sigs.k8s.io/kubebuilder-declarative-pattern/pkg/patterns/addon/pkg/loaders"
+
+	"github.com/yuwenma/moss/moss/pkg/bundle"
+	"github.com/yuwenma/moss/moss/pkg/loaders"
+	"github.com/yuwenma/moss/moss/pkg/transforms"
+)
+
+func main() {
+	var opts bundle.Options
+	var packages, output, registry string
+	flag.StringVar(&opts.Channel, "channel-name", loaders.DefaultChannel, "The channel the versions are resolved from.")
+	flag.StringVar(&packages, "package", "", "Comma separated addons to bundle, all the addons of the channel if empty.")
+	flag.StringVar(&opts.Version, "version", "", "The version of the single --package to bundle, the newest version of the channel if empty.")
+	flag.StringVar(&output, "output", "bundle.tar.gz", "The tarball to write.")
+	flag.StringVar(&registry, "image-registry", "", "If set, the images are printed with the internal registry they are mirrored to.")
+	flag.Parse()
+	// The channels directory is read from --channel, as for the manager.
+	opts.ChannelDir = addonloaders.FlagChannel
+	if packages != "" {
+		opts.Packages = strings.Split(packages, ",")
+	}
+
+	if err := run(context.Background(), opts, output, registry); err != nil {
+		fmt.Fprintf(os.Stderr, "error: %v\n", err)
+		os.Exit(1)
+	}
+}
+
+func run(ctx context.Context, opts bundle.Options, output, registry string) error {
+	f, err := os.Create(output)
+	if err != nil {
+		return err
+	}
+	images, err := bundle.Write(ctx, f, opts)
+	if closeErr := f.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		os.Remove(output)
+		return err
+	}
+	for _, image := range images {
+		if registry != "" {
+			fmt.Printf("%s %s\n", image, transforms.RewriteImage(image, registry))
+		} else {
+			fmt.Println(image)
+		}
+	}
+	return nil
+}
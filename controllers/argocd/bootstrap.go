@@ -0,0 +1,94 @@
+package argocd
+
+import (
+	"context"
+	"encoding/json"
+
+	"github.com/pkg/errors"
+	addonsv1alpha1 "github.com/yuwenma/moss/moss/api/v1alpha1"
+	mossstatus "github.com/yuwenma/moss/moss/pkg/status"
+	"github.com/yuwenma/moss/moss/pkg/transforms"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/log"
+	"sigs.k8s.io/kubebuilder-declarative-pattern/pkg/patterns/declarative"
+	"sigs.k8s.io/kubebuilder-declarative-pattern/pkg/patterns/declarative/pkg/manifest"
+)
+
+var argoprojGroupVersion = schema.GroupVersion{Group: "argoproj.io", Version: "v1alpha1"}
+
+// bootstrapCRDs are the CRDs that must be established before the bootstrap objects are applied.
+var bootstrapCRDs = []string{"appprojects.argoproj.io", "applications.argoproj.io"}
+
+// addBootstrapObjects adds the AppProjects and Applications of `spec.bootstrap` to the manifest once the
+// argocd CRDs are established and the application controller is healthy. Until then only the objects
+// that already exist are kept, so that they are not pruned while ArgoCD is upgraded. The reconciler
+// runs again when the CRDs or the application controller change, as they are watched children.
+func (r *ArgoCDReconciler) addBootstrapObjects(ctx context.Context, o declarative.DeclarativeObject, objects *manifest.Objects) error {
+	argocd, ok := o.(*addonsv1alpha1.ArgoCD)
+	if !ok {
+		return errors.Errorf("expected ArgoCD object, got %T", o)
+	}
+	bootstrap := argocd.Spec.Bootstrap
+	if len(bootstrap.Projects) == 0 && len(bootstrap.Applications) == 0 {
+		return nil
+	}
+
+	var bootstrapObjects []*unstructured.Unstructured
+	for _, group := range []struct {
+		kind    string
+		objects []addonsv1alpha1.ArgoCDBootstrapObject
+	}{
+		{"AppProject", bootstrap.Projects},
+		{"Application", bootstrap.Applications},
+	} {
+		for i, object := range group.objects {
+			u, err := bootstrapObject(group.kind, object)
+			if err != nil {
+				return errors.Wrapf(err, "spec.bootstrap: %s %d", group.kind, i)
+			}
+			bootstrapObjects = append(bootstrapObjects, u)
+		}
+	}
+
+	ready, reason, err := r.bootstrapReady(ctx)
+	if err != nil {
+		return err
+	}
+	if !ready {
+		log.FromContext(ctx).Info("waiting to apply spec.bootstrap", "reason", reason)
+	}
+	return transforms.AddGated(ctx, r.apiReader, objects, ready, bootstrapObjects)
+}
+
+func bootstrapObject(kind string, object addonsv1alpha1.ArgoCDBootstrapObject) (*unstructured.Unstructured, error) {
+	if object.Name == "" {
+		return nil, errors.New("name must be set")
+	}
+	var spec map[string]interface{}
+	if err := json.Unmarshal(object.Spec.Raw, &spec); err != nil || spec == nil {
+		return nil, errors.Errorf("spec of %q must be an object", object.Name)
+	}
+
+	u := &unstructured.Unstructured{Object: map[string]interface{}{"spec": spec}}
+	u.SetGroupVersionKind(argoprojGroupVersion.WithKind(kind))
+	u.SetNamespace(ArgoCDNamespace)
+	u.SetName(object.Name)
+	u.SetLabels(object.Labels)
+	u.SetAnnotations(object.Annotations)
+	u.SetFinalizers(object.Finalizers)
+	return u, nil
+}
+
+// bootstrapReady reports whether the bootstrap objects can be applied, or the reason they can not.
+func (r *ArgoCDReconciler) bootstrapReady(ctx context.Context) (bool, string, error) {
+	for _, name := range bootstrapCRDs {
+		ready, reason, err := mossstatus.IsCurrent(ctx, r.apiReader, mossstatus.CustomResourceDefinitionGVK, types.NamespacedName{Name: name})
+		if err != nil || !ready {
+			return false, reason, err
+		}
+	}
+	gvk := schema.GroupVersionKind{Group: "apps", Version: "v1", Kind: applicationControllerWorkload.Kind}
+	return mossstatus.IsCurrent(ctx, r.apiReader, gvk, types.NamespacedName{Namespace: ArgoCDNamespace, Name: applicationControllerWorkload.Name})
+}
@@ -0,0 +1,106 @@
+package argocd
+
+import (
+	"context"
+	"os"
+	"reflect"
+	"testing"
+
+	addonsv1alpha1 "github.com/yuwenma/moss/moss/api/v1alpha1"
+	mossstatus "github.com/yuwenma/moss/moss/pkg/status"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+	"sigs.k8s.io/kubebuilder-declarative-pattern/pkg/patterns/declarative/pkg/manifest"
+	"sigs.k8s.io/yaml"
+)
+
+// TestAddBootstrapObjectsNotReady checks that the bootstrap objects are held back while the application
+// controller is not ready, except those which already exist.
+func TestAddBootstrapObjectsNotReady(t *testing.T) {
+	ctx := context.Background()
+	argocd := &addonsv1alpha1.ArgoCD{}
+	b, err := os.ReadFile("tests/bootstrap.in.yaml")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := yaml.Unmarshal(b, argocd); err != nil {
+		t.Fatal(err)
+	}
+
+	established := func(name string) client.Object {
+		crd := &unstructured.Unstructured{Object: map[string]interface{}{
+			"status": map[string]interface{}{"conditions": []interface{}{
+				map[string]interface{}{"type": "Established", "status": "True"},
+				map[string]interface{}{"type": "NamesAccepted", "status": "True"},
+			}},
+		}}
+		crd.SetGroupVersionKind(mossstatus.CustomResourceDefinitionGVK)
+		crd.SetName(name)
+		return crd
+	}
+	// The application controller is still rolling out.
+	applicationController := &unstructured.Unstructured{Object: map[string]interface{}{
+		"spec":   map[string]interface{}{"replicas": int64(1)},
+		"status": map[string]interface{}{"replicas": int64(1)},
+	}}
+	applicationController.SetAPIVersion("apps/v1")
+	applicationController.SetKind("StatefulSet")
+	applicationController.SetNamespace(ArgoCDNamespace)
+	applicationController.SetName("argocd-application-controller")
+	applicationController.SetGeneration(1)
+
+	readyApplicationController := applicationController.DeepCopy()
+	readyApplicationController.Object["status"] = map[string]interface{}{
+		"observedGeneration": int64(1), "replicas": int64(1), "readyReplicas": int64(1), "currentReplicas": int64(1),
+		"updatedReplicas": int64(1), "currentRevision": "r1", "updateRevision": "r1",
+	}
+
+	tests := []struct {
+		name     string
+		existing []client.Object
+		want     []string
+	}{
+		{name: "CRDs not installed"},
+		{
+			name:     "application controller not ready",
+			existing: []client.Object{established("appprojects.argoproj.io"), established("applications.argoproj.io"), applicationController},
+		},
+		{
+			name: "AppProject already applied",
+			existing: []client.Object{
+				established("appprojects.argoproj.io"), established("applications.argoproj.io"), applicationController,
+				func() client.Object {
+					u := &unstructured.Unstructured{}
+					u.SetGroupVersionKind(argoprojGroupVersion.WithKind("AppProject"))
+					u.SetNamespace(ArgoCDNamespace)
+					u.SetName("platform")
+					return u
+				}(),
+			},
+			want: []string{"AppProject/platform"},
+		},
+		{
+			name:     "ready",
+			existing: []client.Object{established("appprojects.argoproj.io"), established("applications.argoproj.io"), readyApplicationController},
+			want:     []string{"AppProject/platform", "Application/root"},
+		},
+	}
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			r := &ArgoCDReconciler{apiReader: fake.NewClientBuilder().WithScheme(runtime.NewScheme()).WithObjects(tc.existing...).Build()}
+			objects := &manifest.Objects{}
+			if err := r.addBootstrapObjects(ctx, argocd, objects); err != nil {
+				t.Fatalf("addBootstrapObjects() error = %v", err)
+			}
+			var got []string
+			for _, object := range objects.Items {
+				got = append(got, object.Kind+"/"+object.GetName())
+			}
+			if !reflect.DeepEqual(got, tc.want) {
+				t.Errorf("addBootstrapObjects() added %v, want %v", got, tc.want)
+			}
+		})
+	}
+}
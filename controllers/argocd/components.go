@@ -0,0 +1,125 @@
+package argocd
+
+import (
+	"context"
+	"strconv"
+	"strings"
+
+	"github.com/pkg/errors"
+	addonsv1alpha1 "github.com/yuwenma/moss/moss/api/v1alpha1"
+	"github.com/yuwenma/moss/moss/pkg/transforms"
+	"sigs.k8s.io/controller-runtime/pkg/log"
+	"sigs.k8s.io/kubebuilder-declarative-pattern/pkg/patterns/declarative"
+	"sigs.k8s.io/kubebuilder-declarative-pattern/pkg/patterns/declarative/pkg/manifest"
+)
+
+// Every object of an optional component is named after the component, e.g. argocd-dex-server,
+// argocd-dex-server-network-policy, argocd-notifications-cm.
+const (
+	dexPrefix            = "argocd-dex-server"
+	notificationsPrefix  = "argocd-notifications"
+	applicationSetPrefix = "argocd-applicationset-controller"
+)
+
+var (
+	serverWorkload                = transforms.Workload{Kind: "Deployment", Name: "argocd-server", Container: "argocd-server"}
+	repoServerWorkload            = transforms.Workload{Kind: "Deployment", Name: "argocd-repo-server", Container: "argocd-repo-server"}
+	applicationControllerWorkload = transforms.Workload{Kind: "StatefulSet", Name: "argocd-application-controller", Container: "argocd-application-controller"}
+	dexWorkload                   = transforms.Workload{Kind: "Deployment", Name: "argocd-dex-server", Container: "dex"}
+	notificationsWorkload         = transforms.Workload{Kind: "Deployment", Name: "argocd-notifications-controller", Container: "argocd-notifications-controller"}
+	applicationSetWorkload        = transforms.Workload{Kind: "Deployment", Name: "argocd-applicationset-controller", Container: "argocd-applicationset-controller"}
+)
+
+// removeDisabledComponents drops the objects of the optional components disabled in `spec.components`.
+// Objects applied by an earlier reconcile are then pruned by the applyset applier.
+// CustomResourceDefinitions are always kept, removing them would delete the users' custom resources.
+func removeDisabledComponents(ctx context.Context, o declarative.DeclarativeObject, objects *manifest.Objects) error {
+	argocd, ok := o.(*addonsv1alpha1.ArgoCD)
+	if !ok {
+		return errors.Errorf("expected ArgoCD object, got %T", o)
+	}
+	components := argocd.Spec.Components
+
+	var disabled []string
+	if !components.Dex.IsEnabled() {
+		disabled = append(disabled, dexPrefix)
+	}
+	if !components.Notifications.IsEnabled() {
+		disabled = append(disabled, notificationsPrefix)
+	}
+	if !components.ApplicationSet.IsEnabled() {
+		disabled = append(disabled, applicationSetPrefix)
+	}
+	if len(disabled) == 0 {
+		return nil
+	}
+
+	log := log.FromContext(ctx)
+	var items []*manifest.Object
+	for _, object := range objects.Items {
+		if object.Kind != "CustomResourceDefinition" && hasAnyPrefix(object.GetName(), disabled) {
+			log.WithValues("kind", object.Kind).WithValues("name", object.GetName()).Info("skipping object of disabled component")
+			continue
+		}
+		items = append(items, object)
+	}
+	objects.Items = items
+	return nil
+}
+
+// applyComponentSettings writes the replicas, resources and scheduling settings of `spec.components`
+// into the workloads of the manifest. It must run after removeDisabledComponents.
+func applyComponentSettings(ctx context.Context, o declarative.DeclarativeObject, objects *manifest.Objects) error {
+	argocd, ok := o.(*addonsv1alpha1.ArgoCD)
+	if !ok {
+		return errors.Errorf("expected ArgoCD object, got %T", o)
+	}
+	components := argocd.Spec.Components
+
+	type setting struct {
+		workload transforms.Workload
+		spec     *addonsv1alpha1.WorkloadSpec
+	}
+	settings := []setting{
+		{serverWorkload, components.Server},
+		{repoServerWorkload, components.RepoServer},
+		{applicationControllerWorkload, components.ApplicationController},
+	}
+	if components.Dex.IsEnabled() {
+		settings = append(settings, setting{dexWorkload, components.Dex.Workload()})
+	}
+	if components.Notifications.IsEnabled() {
+		settings = append(settings, setting{notificationsWorkload, components.Notifications.Workload()})
+	}
+	if components.ApplicationSet.IsEnabled() {
+		settings = append(settings, setting{applicationSetWorkload, components.ApplicationSet.Workload()})
+	}
+	for _, s := range settings {
+		if err := transforms.ApplyWorkloadSpec(objects, s.workload, s.spec); err != nil {
+			return errors.Wrap(err, "spec.components")
+		}
+	}
+
+	// The API server and the application controller are told their replica count, the application
+	// controller shards clusters across its replicas.
+	if spec := components.Server; spec != nil && spec.Replicas != nil {
+		if err := transforms.SetEnv(objects, serverWorkload, "ARGOCD_API_SERVER_REPLICAS", strconv.Itoa(int(*spec.Replicas))); err != nil {
+			return err
+		}
+	}
+	if spec := components.ApplicationController; spec != nil && spec.Replicas != nil {
+		if err := transforms.SetEnv(objects, applicationControllerWorkload, "ARGOCD_CONTROLLER_REPLICAS", strconv.Itoa(int(*spec.Replicas))); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func hasAnyPrefix(s string, prefixes []string) bool {
+	for _, prefix := range prefixes {
+		if strings.HasPrefix(s, prefix) {
+			return true
+		}
+	}
+	return false
+}
@@ -2,12 +2,19 @@ package argocd
 
 import (
 	"context"
+	"time"
 
-	addonsv1alpha1 "github.com/yuwenma/moss/moss/api/v1alpha1"
 	"github.com/go-logr/logr"
 	"github.com/pkg/errors"
+	addonsv1alpha1 "github.com/yuwenma/moss/moss/api/v1alpha1"
+	"github.com/yuwenma/moss/moss/pkg/loaders"
+	mossstatus "github.com/yuwenma/moss/moss/pkg/status"
+	"github.com/yuwenma/moss/moss/pkg/transforms"
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
 	ctrl "sigs.k8s.io/controller-runtime"
 	"sigs.k8s.io/controller-runtime/pkg/client"
 	"sigs.k8s.io/controller-runtime/pkg/controller"
@@ -16,6 +23,7 @@ import (
 	"sigs.k8s.io/controller-runtime/pkg/reconcile"
 	"sigs.k8s.io/controller-runtime/pkg/source"
 	"sigs.k8s.io/kubebuilder-declarative-pattern/pkg/patterns/addon"
+	addonloaders "sigs.k8s.io/kubebuilder-declarative-pattern/pkg/patterns/addon/pkg/loaders"
 	"sigs.k8s.io/kubebuilder-declarative-pattern/pkg/patterns/addon/pkg/status"
 	"sigs.k8s.io/kubebuilder-declarative-pattern/pkg/patterns/declarative"
 	"sigs.k8s.io/kubebuilder-declarative-pattern/pkg/patterns/declarative/pkg/applier"
@@ -24,6 +32,9 @@ import (
 
 const (
 	ArgoCDNamespace = "argocd"
+
+	// standardVariant is the package variant holding the non-HA manifest.
+	standardVariant = "standard"
 )
 
 var _ reconcile.Reconciler = &ArgoCDReconciler{}
@@ -34,6 +45,12 @@ type ArgoCDReconciler struct {
 	Log    logr.Logger
 	Scheme *runtime.Scheme
 
+	// apiReader reads the objects the manifest depends on, e.g. the Secrets referenced by
+	// spec.repositories, bypassing the cache.
+	apiReader client.Reader
+	// loader loads the ArgoCD package, and records the version it resolved.
+	loader *loaders.ManifestLoader
+
 	declarative.Reconciler
 }
 
@@ -43,6 +60,7 @@ type ArgoCDReconciler struct {
 // SetupWithManager sets up the controller with the Manager.
 func (r *ArgoCDReconciler) SetupWithManager(mgr ctrl.Manager) error {
 	addon.Init()
+	r.apiReader = mgr.GetAPIReader()
 
 	// TODO: use the applyset recommended labels.
 	labels := map[string]string{
@@ -52,7 +70,26 @@ func (r *ArgoCDReconciler) SetupWithManager(mgr ctrl.Manager) error {
 	applier := applier.NewApplySetApplier(metav1.PatchOptions{}, metav1.DeleteOptions{}, applier.ApplysetOptions{})
 	watchLabels := declarative.SourceLabel(mgr.GetScheme())
 
+	serverVersion, err := loaders.DiscoveryServerVersion(mgr.GetConfig())
+	if err != nil {
+		return err
+	}
+	loader, err := loaders.NewManifestLoader(addonloaders.FlagChannel, manifestVariant, serverVersion)
+	if err != nil {
+		return err
+	}
+	r.loader = loader
+
 	if err := r.Reconciler.Init(mgr, &addonsv1alpha1.ArgoCD{},
+		declarative.WithManifestController(loader),
+		// Only the images of the package are pulled from the internal registry, they are the images
+		// bundled for air-gapped clusters.
+		declarative.WithObjectTransform(transforms.RewriteImages),
+		// Transforms adding objects run before the labels are added.
+		declarative.WithObjectTransform(r.addRepositorySecrets),
+		declarative.WithObjectTransform(exposeServer),
+		declarative.WithObjectTransform(r.addBootstrapObjects),
+		declarative.WithObjectTransform(addPlugins),
 		declarative.WithLabels(watchLabels),
 		declarative.WithObjectTransform(declarative.AddLabels(labels)),
 		declarative.WithOwner(declarative.SourceAsOwner),
@@ -60,7 +97,15 @@ func (r *ArgoCDReconciler) SetupWithManager(mgr ctrl.Manager) error {
 			declarative.AddLabels(labels),
 		),
 		// TODO: Define `ArgoCD.Status` to ack users the health status; k-d-p side needs to extend the kstatus support.
-		declarative.WithStatus(status.NewKstatusCheck(mgr.GetClient(), &r.Reconciler)),
+		declarative.WithStatus(mossstatus.WithEvents(mgr.GetEventRecorderFor("argocd-controller"),
+			mossstatus.WithAddonStatus(mgr.GetClient(),
+				mossstatus.WithReconcileErrors(status.NewKstatusCheck(mgr.GetClient(), &r.Reconciler)),
+				r.buildStatus,
+			),
+		)),
+		declarative.WithObjectTransform(removeDisabledComponents),
+		declarative.WithObjectTransform(applyComponentSettings),
+		declarative.WithObjectTransform(applySettings),
 		declarative.WithObjectTransform(addon.ApplyPatches),
 		declarative.WithApplier(applier),
 		declarative.WithApplyPrune(),
@@ -93,9 +138,60 @@ func (r *ArgoCDReconciler) SetupWithManager(mgr ctrl.Manager) error {
 		return err
 	}
 
+	// Watch for changes to the Secrets holding repository credentials. Only their metadata is cached,
+	// the credentials are read through the apiReader.
+	err = mgr.GetFieldIndexer().IndexField(context.Background(), &addonsv1alpha1.ArgoCD{}, secretReferenceIndex, referencedSecrets)
+	if err != nil {
+		return err
+	}
+	secret := &metav1.PartialObjectMetadata{}
+	secret.SetGroupVersionKind(corev1.SchemeGroupVersion.WithKind("Secret"))
+	err = c.Watch(&source.Kind{Type: secret}, handler.EnqueueRequestsFromMapFunc(r.argoCDsReferencingSecret))
+	if err != nil {
+		return err
+	}
+
 	return nil
 }
 
+// Reconcile reconciles the ArgoCD object, and requeues it to pick up the new versions of its channel.
+func (r *ArgoCDReconciler) Reconcile(ctx context.Context, req reconcile.Request) (reconcile.Result, error) {
+	result, err := r.Reconciler.Reconcile(ctx, req)
+	if err != nil || result.Requeue || result.RequeueAfter != 0 {
+		return result, err
+	}
+	deleted, err := r.deleted(ctx, req.NamespacedName)
+	if err != nil {
+		return result, err
+	}
+	if deleted {
+		r.loader.Forget(req.NamespacedName)
+		return result, nil
+	}
+	result.RequeueAfter = r.loader.RequeueAfter(req.NamespacedName, time.Now())
+	return result, nil
+}
+
+// deleted returns whether the ArgoCD object key is gone or being deleted, so that it is no longer requeued.
+func (r *ArgoCDReconciler) deleted(ctx context.Context, key types.NamespacedName) (bool, error) {
+	o := &addonsv1alpha1.ArgoCD{}
+	if err := r.Client.Get(ctx, key, o); err != nil {
+		if apierrors.IsNotFound(err) {
+			return true, nil
+		}
+		return false, errors.Wrapf(err, "error reading ArgoCD %s", key)
+	}
+	return !o.GetDeletionTimestamp().IsZero(), nil
+}
+
+// manifestVariant selects the non-HA package of the requested version when ArgoCD runs in Standard mode.
+func manifestVariant(object runtime.Object) string {
+	if o, ok := object.(*addonsv1alpha1.ArgoCD); ok && o.Spec.Mode == addonsv1alpha1.ArgoCDModeStandard {
+		return standardVariant
+	}
+	return ""
+}
+
 // namespaceExist guarantees the `ArgoCD` namespace exist in the cluster. This is not necessary if the deployment manifest
 // already contains the namespace object. But we can not guarantee this because
 //  1. The current upstream OSS ArgoCD does not contain "argocd" namespace object.
@@ -130,3 +226,8 @@ func (r *ArgoCDReconciler) namespaceExist(ctx context.Context, objects *manifest
 // +kubebuilder:rbac:groups="",resources=services,verbs=get;list;watch;create;update;delete;patch
 // +kubebuilder:rbac:groups="",resources=events,verbs=get;list;watch;create;update;delete;patch
 // +kubebuilder:rbac:groups=apps;extensions,resources=deployments,verbs=get;list;watch;create;update;delete;patch
+// +kubebuilder:rbac:groups=apps,resources=statefulsets,verbs=get;list;watch;create;update;delete;patch
+// +kubebuilder:rbac:groups=apiextensions.k8s.io,resources=customresourcedefinitions,verbs=get;list;watch;create;update;delete;patch
+// +kubebuilder:rbac:groups=networking.k8s.io,resources=ingresses,verbs=get;list;watch;create;update;delete;patch
+// +kubebuilder:rbac:groups=gateway.networking.k8s.io,resources=httproutes,verbs=get;list;watch;create;update;delete;patch
+// +kubebuilder:rbac:groups=argoproj.io,resources=appprojects;applications,verbs=get;list;watch;create;update;delete;patch
@@ -4,18 +4,29 @@ import (
 	"testing"
 
 	addonsv1alpha1 "github.com/yuwenma/moss/moss/api/v1alpha1"
+	"github.com/yuwenma/moss/moss/pkg/testutil"
+	clientgoscheme "k8s.io/client-go/kubernetes/scheme"
+	"sigs.k8s.io/controller-runtime/pkg/scheme"
 	"sigs.k8s.io/kubebuilder-declarative-pattern/pkg/test/golden"
 )
 
 func TestArgoCDController(t *testing.T) {
-	validator := golden.NewValidator(t, addonsv1alpha1.SchemeBuilder)
+	// Core types are registered for reading the Secrets referenced by spec.repositories.
+	schemeBuilder := &scheme.Builder{}
+	schemeBuilder.SchemeBuilder.Register(clientgoscheme.AddToScheme, addonsv1alpha1.AddToScheme)
+	validator := golden.NewValidator(t, schemeBuilder)
 	dr := &ArgoCDReconciler{
 		Client: validator.Client(),
 	}
+	// The ArgoCD objects are indexed by the Secrets they reference, which needs their CRD.
+	testutil.CreateObjects(t, validator.Client(), "../../config/crd/bases/configdelivery.anthos.io_argocds.yaml")
 	err := dr.SetupWithManager(validator.Manager())
 	if err != nil {
 		t.Fatalf("creating reconciler: %v", err)
 	}
 
+	testutil.CreateObjects(t, validator.Client(), "tests/secrets.yaml")
+	// The argocd CRDs and application controller are ready, so that spec.bootstrap is rendered.
+	testutil.CreateObjects(t, validator.Client(), "tests/installed.yaml")
 	validator.Validate(dr.Reconciler)
 }
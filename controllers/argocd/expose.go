@@ -0,0 +1,180 @@
+package argocd
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/pkg/errors"
+	addonsv1alpha1 "github.com/yuwenma/moss/moss/api/v1alpha1"
+	"github.com/yuwenma/moss/moss/pkg/transforms"
+	corev1 "k8s.io/api/core/v1"
+	networkingv1 "k8s.io/api/networking/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/kubebuilder-declarative-pattern/pkg/patterns/declarative"
+	"sigs.k8s.io/kubebuilder-declarative-pattern/pkg/patterns/declarative/pkg/manifest"
+)
+
+const (
+	serverName         = "argocd-server"
+	cmdParamsConfigMap = "argocd-cmd-params-cm"
+	// serverHTTPPort is the port of the argocd-server Service serving plain HTTP once `server.insecure` is set.
+	serverHTTPPort = 80
+)
+
+// exposeServer sets the type of the argocd-server Service and generates the Ingress or HTTPRoute of
+// `spec.server.expose`. Objects generated for a previous spec are pruned by the applier.
+func exposeServer(ctx context.Context, o declarative.DeclarativeObject, objects *manifest.Objects) error {
+	argocd, ok := o.(*addonsv1alpha1.ArgoCD)
+	if !ok {
+		return errors.Errorf("expected ArgoCD object, got %T", o)
+	}
+	expose := argocd.Spec.Server.Expose
+	if expose == nil {
+		return nil
+	}
+	if expose.Ingress != nil && expose.HTTPRoute != nil {
+		return errors.New("spec.server.expose: at most one of ingress and httpRoute may be set")
+	}
+
+	if expose.ServiceType != "" {
+		service, err := transforms.FindObject(objects, "Service", serverName)
+		if err != nil {
+			return err
+		}
+		if err := service.SetNestedField(string(expose.ServiceType), "spec", "type"); err != nil {
+			return errors.Wrap(err, "setting type of Service argocd-server")
+		}
+	}
+
+	if expose.Ingress == nil && expose.HTTPRoute == nil {
+		return nil
+	}
+	// TLS is terminated by the ingress controller or the Gateway.
+	if err := transforms.SetConfigMapData(objects, cmdParamsConfigMap, map[string]string{"server.insecure": "true"}); err != nil {
+		return err
+	}
+	if expose.Ingress != nil {
+		if expose.Ingress.Hostname == "" {
+			return errors.New("spec.server.expose.ingress.hostname must be set")
+		}
+		return transforms.AddObject(objects, serverIngress(expose.Ingress))
+	}
+	if expose.HTTPRoute.Hostname == "" {
+		return errors.New("spec.server.expose.httpRoute.hostname must be set")
+	}
+	return transforms.AddObject(objects, serverHTTPRoute(expose.HTTPRoute))
+}
+
+func serverIngress(spec *addonsv1alpha1.ArgoCDServerIngress) *networkingv1.Ingress {
+	pathType := networkingv1.PathTypePrefix
+	ingress := &networkingv1.Ingress{
+		TypeMeta: metav1.TypeMeta{APIVersion: "networking.k8s.io/v1", Kind: "Ingress"},
+		ObjectMeta: metav1.ObjectMeta{
+			Name:        serverName,
+			Namespace:   ArgoCDNamespace,
+			Annotations: spec.Annotations,
+		},
+		Spec: networkingv1.IngressSpec{
+			IngressClassName: spec.IngressClassName,
+			Rules: []networkingv1.IngressRule{{
+				Host: spec.Hostname,
+				IngressRuleValue: networkingv1.IngressRuleValue{HTTP: &networkingv1.HTTPIngressRuleValue{
+					Paths: []networkingv1.HTTPIngressPath{{
+						Path:     "/",
+						PathType: &pathType,
+						Backend: networkingv1.IngressBackend{Service: &networkingv1.IngressServiceBackend{
+							Name: serverName,
+							Port: networkingv1.ServiceBackendPort{Number: serverHTTPPort},
+						}},
+					}},
+				}},
+			}},
+		},
+	}
+	if spec.TLSSecretName != "" {
+		ingress.Spec.TLS = []networkingv1.IngressTLS{{
+			Hosts:      []string{spec.Hostname},
+			SecretName: spec.TLSSecretName,
+		}}
+	}
+	return ingress
+}
+
+// serverHTTPRoute builds the HTTPRoute as an unstructured object, the Gateway API types are not vendored.
+func serverHTTPRoute(spec *addonsv1alpha1.ArgoCDServerHTTPRoute) *unstructured.Unstructured {
+	var parentRefs []interface{}
+	for _, ref := range spec.ParentRefs {
+		parentRef := map[string]interface{}{
+			"namespace": ref.Namespace,
+			"name":      ref.Name,
+		}
+		if ref.SectionName != "" {
+			parentRef["sectionName"] = ref.SectionName
+		}
+		parentRefs = append(parentRefs, parentRef)
+	}
+	return &unstructured.Unstructured{Object: map[string]interface{}{
+		"apiVersion": "gateway.networking.k8s.io/v1beta1",
+		"kind":       "HTTPRoute",
+		"metadata": map[string]interface{}{
+			"name":      serverName,
+			"namespace": ArgoCDNamespace,
+		},
+		"spec": map[string]interface{}{
+			"parentRefs": parentRefs,
+			"hostnames":  []interface{}{spec.Hostname},
+			"rules": []interface{}{
+				map[string]interface{}{
+					"backendRefs": []interface{}{
+						map[string]interface{}{
+							"name": serverName,
+							"port": int64(serverHTTPPort),
+						},
+					},
+				},
+			},
+		},
+	}}
+}
+
+// externalURL returns the URL argocd-server is exposed at. For a LoadBalancer Service it is only known
+// once the load balancer has been provisioned. A NodePort Service has no URL, as the addresses of the
+// nodes it is reachable at are not known.
+func externalURL(ctx context.Context, argocd *addonsv1alpha1.ArgoCD, info *declarative.StatusInfo) (string, error) {
+	expose := argocd.Spec.Server.Expose
+	switch {
+	case expose == nil:
+		return "", nil
+	case expose.HTTPRoute != nil:
+		return "https://" + expose.HTTPRoute.Hostname, nil
+	case expose.Ingress != nil && expose.Ingress.TLSSecretName != "":
+		return "https://" + expose.Ingress.Hostname, nil
+	case expose.Ingress != nil:
+		return "http://" + expose.Ingress.Hostname, nil
+	case expose.ServiceType != corev1.ServiceTypeLoadBalancer || info.LiveObjects == nil:
+		return "", nil
+	}
+
+	service, err := info.LiveObjects(ctx, corev1.SchemeGroupVersion.WithKind("Service"), types.NamespacedName{Namespace: ArgoCDNamespace, Name: serverName})
+	if apierrors.IsNotFound(err) {
+		// The apply failed before the Service was created.
+		return "", nil
+	}
+	if err != nil {
+		return "", errors.Wrap(err, "reading Service argocd-server")
+	}
+	ingress, _, err := unstructured.NestedSlice(service.Object, "status", "loadBalancer", "ingress")
+	if err != nil || len(ingress) == 0 {
+		return "", err
+	}
+	address, _ := ingress[0].(map[string]interface{})
+	for _, key := range []string{"hostname", "ip"} {
+		if host, ok := address[key].(string); ok && host != "" {
+			return fmt.Sprintf("https://%s", host), nil
+		}
+	}
+	return "", nil
+}
@@ -0,0 +1,52 @@
+package argocd
+
+import (
+	"context"
+	"testing"
+
+	addonsv1alpha1 "github.com/yuwenma/moss/moss/api/v1alpha1"
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/kubebuilder-declarative-pattern/pkg/patterns/declarative"
+)
+
+func TestExternalURL(t *testing.T) {
+	ctx := context.Background()
+	argocd := &addonsv1alpha1.ArgoCD{}
+	argocd.Spec.Server.Expose = &addonsv1alpha1.ArgoCDServerExpose{ServiceType: corev1.ServiceTypeLoadBalancer}
+	service := &unstructured.Unstructured{Object: map[string]interface{}{
+		"status": map[string]interface{}{"loadBalancer": map[string]interface{}{
+			"ingress": []interface{}{map[string]interface{}{"ip": "203.0.113.10"}},
+		}},
+	}}
+
+	tests := []struct {
+		name    string
+		service *unstructured.Unstructured
+		err     error
+		want    string
+		wantErr bool
+	}{
+		{name: "provisioned", service: service, want: "https://203.0.113.10"},
+		{name: "pending", service: &unstructured.Unstructured{Object: map[string]interface{}{}}},
+		// The apply failed before the Service was created.
+		{name: "not created", err: apierrors.NewNotFound(schema.GroupResource{Resource: "services"}, serverName)},
+		{name: "read failed", err: apierrors.NewForbidden(schema.GroupResource{Resource: "services"}, serverName, nil), wantErr: true},
+	}
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			info := &declarative.StatusInfo{
+				LiveObjects: func(context.Context, schema.GroupVersionKind, types.NamespacedName) (*unstructured.Unstructured, error) {
+					return tc.service, tc.err
+				},
+			}
+			got, err := externalURL(ctx, argocd, info)
+			if (err != nil) != tc.wantErr || got != tc.want {
+				t.Errorf("externalURL() = %q, %v, want %q, error %t", got, err, tc.want, tc.wantErr)
+			}
+		})
+	}
+}
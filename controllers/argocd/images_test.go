@@ -0,0 +1,128 @@
+package argocd
+
+import (
+	"context"
+	"os"
+	"strings"
+	"testing"
+	"time"
+
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/apimachinery/pkg/util/wait"
+	clientgoscheme "k8s.io/client-go/kubernetes/scheme"
+	"k8s.io/client-go/rest"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/manager"
+	"sigs.k8s.io/kubebuilder-declarative-pattern/mockkubeapiserver"
+	addonloaders "sigs.k8s.io/kubebuilder-declarative-pattern/pkg/patterns/addon/pkg/loaders"
+	"sigs.k8s.io/yaml"
+
+	addonsv1alpha1 "github.com/yuwenma/moss/moss/api/v1alpha1"
+	"github.com/yuwenma/moss/moss/pkg/testutil"
+	"github.com/yuwenma/moss/moss/pkg/transforms"
+)
+
+// TestRewriteImagesSkipsPlugins checks that only the images of the package are pulled from the internal
+// registry, as the images of spec.plugins are not bundled.
+func TestRewriteImagesSkipsPlugins(t *testing.T) {
+	ctx := context.Background()
+
+	flagChannel := addonloaders.FlagChannel
+	addonloaders.FlagChannel = "../../channels"
+	t.Cleanup(func() { addonloaders.FlagChannel = flagChannel })
+	imageRegistry := transforms.ImageRegistry
+	transforms.ImageRegistry = "registry.internal"
+	t.Cleanup(func() { transforms.ImageRegistry = imageRegistry })
+
+	k8s, err := mockkubeapiserver.NewMockKubeAPIServer(":0")
+	if err != nil {
+		t.Fatalf("building mock kube-apiserver: %v", err)
+	}
+	addr, err := k8s.StartServing()
+	if err != nil {
+		t.Fatalf("starting mock kube-apiserver: %v", err)
+	}
+	defer k8s.Stop()
+
+	s := runtime.NewScheme()
+	if err := clientgoscheme.AddToScheme(s); err != nil {
+		t.Fatal(err)
+	}
+	if err := addonsv1alpha1.AddToScheme(s); err != nil {
+		t.Fatal(err)
+	}
+	mgr, err := manager.New(&rest.Config{Host: addr.String()}, manager.Options{Scheme: s, MetricsBindAddress: "0"})
+	if err != nil {
+		t.Fatalf("building manager: %v", err)
+	}
+	testutil.CreateObjects(t, mgr.GetClient(), "../../config/crd/bases/configdelivery.anthos.io_argocds.yaml")
+	dr := &ArgoCDReconciler{
+		Client: mgr.GetClient(),
+	}
+	if err := dr.SetupWithManager(mgr); err != nil {
+		t.Fatalf("creating reconciler: %v", err)
+	}
+
+	cacheCtx, stop := context.WithCancel(ctx)
+	defer stop()
+	go func() {
+		if err := mgr.GetCache().Start(cacheCtx); err != nil {
+			t.Errorf("starting cache: %v", err)
+		}
+	}()
+	mgr.GetCache().WaitForCacheSync(cacheCtx)
+
+	b, err := os.ReadFile("tests/plugins.in.yaml")
+	if err != nil {
+		t.Fatal(err)
+	}
+	argocd := &addonsv1alpha1.ArgoCD{}
+	if err := yaml.Unmarshal(b, argocd); err != nil {
+		t.Fatal(err)
+	}
+	if err := mgr.GetClient().Create(ctx, argocd); err != nil {
+		t.Fatalf("creating ArgoCD: %v", err)
+	}
+	// The reconciler reads the ArgoCD from the cache.
+	key := types.NamespacedName{Name: argocd.Name}
+	err = wait.PollImmediate(10*time.Millisecond, 10*time.Second, func() (bool, error) {
+		err := mgr.GetClient().Get(ctx, key, &addonsv1alpha1.ArgoCD{})
+		return err == nil, client.IgnoreNotFound(err)
+	})
+	if err != nil {
+		t.Fatalf("waiting for the ArgoCD to be cached: %v", err)
+	}
+	// The mock apiserver does not serve the status subresource, so writing the status fails with NotFound
+	// after the objects are applied.
+	if _, err := dr.Reconcile(ctx, ctrl.Request{NamespacedName: key}); err != nil && !apierrors.IsNotFound(err) {
+		t.Fatalf("reconciling: %v", err)
+	}
+
+	repoServer := &unstructured.Unstructured{}
+	repoServer.SetAPIVersion("apps/v1")
+	repoServer.SetKind("Deployment")
+	if err := mgr.GetAPIReader().Get(ctx, types.NamespacedName{Namespace: ArgoCDNamespace, Name: "argocd-repo-server"}, repoServer); err != nil {
+		t.Fatalf("reading argocd-repo-server: %v", err)
+	}
+	containers, _, _ := unstructured.NestedSlice(repoServer.Object, "spec", "template", "spec", "containers")
+	images := map[string]string{}
+	for _, c := range containers {
+		container := c.(map[string]interface{})
+		images[container["name"].(string)], _ = container["image"].(string)
+	}
+	if image := images["argocd-repo-server"]; !strings.HasPrefix(image, "registry.internal/") {
+		t.Errorf("argocd-repo-server image = %s, want it pulled from registry.internal", image)
+	}
+	for name, want := range map[string]string{
+		pluginPrefix + "kustomize-envsubst": "registry.k8s.io/kustomize/kustomize:v5.0.1",
+		pluginPrefix + "cue":                "cuelang/cue:0.5.0",
+	} {
+		if images[name] != want {
+			t.Errorf("%s image = %q, want %s", name, images[name], want)
+		}
+	}
+}
@@ -0,0 +1,266 @@
+package argocd
+
+import (
+	"context"
+	"strings"
+
+	"github.com/pkg/errors"
+	addonsv1alpha1 "github.com/yuwenma/moss/moss/api/v1alpha1"
+	"github.com/yuwenma/moss/moss/pkg/transforms"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/util/validation"
+	"sigs.k8s.io/kubebuilder-declarative-pattern/pkg/patterns/declarative"
+	"sigs.k8s.io/kubebuilder-declarative-pattern/pkg/patterns/declarative/pkg/manifest"
+	"sigs.k8s.io/yaml"
+)
+
+const (
+	// pluginsConfigMap holds the plugin.yaml of every plugin, under the key <name>.yaml.
+	pluginsConfigMap = "argocd-cmp-plugins"
+	pluginsVolume    = "cmp-plugins"
+	pluginPrefix     = "cmp-"
+	// argocdUser is the uid argocd-cmp-server must run as to share the plugin sockets with argocd-repo-server.
+	argocdUser = int64(999)
+)
+
+// addPlugins adds a sidecar to argocd-repo-server for each of `spec.plugins`, and the ConfigMap holding
+// their plugin.yaml. The plugins are validated first, so that a bad plugin fails the reconcile instead
+// of the rollout of argocd-repo-server.
+func addPlugins(ctx context.Context, o declarative.DeclarativeObject, objects *manifest.Objects) error {
+	argocd, ok := o.(*addonsv1alpha1.ArgoCD)
+	if !ok {
+		return errors.Errorf("expected ArgoCD object, got %T", o)
+	}
+	plugins := argocd.Spec.Plugins
+	if len(plugins) == 0 {
+		return nil
+	}
+
+	repoServer, err := repoServerWorkload.Find(objects)
+	if err != nil {
+		return err
+	}
+	volumes, err := volumeNames(repoServer)
+	if err != nil {
+		return err
+	}
+	if err := validatePlugins(plugins, volumes); err != nil {
+		return errors.Wrap(err, "spec.plugins")
+	}
+
+	configMap := &corev1.ConfigMap{
+		TypeMeta: metav1.TypeMeta{APIVersion: "v1", Kind: "ConfigMap"},
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      pluginsConfigMap,
+			Namespace: ArgoCDNamespace,
+		},
+		Data: map[string]string{},
+	}
+	var containers, podVolumes []interface{}
+	for i := range plugins {
+		plugin := &plugins[i]
+		config, err := pluginConfig(plugin)
+		if err != nil {
+			return errors.Wrapf(err, "rendering plugin.yaml of plugin %q", plugin.Name)
+		}
+		configMap.Data[plugin.Name+".yaml"] = config
+
+		container, err := toUnstructured(pluginContainer(plugin))
+		if err != nil {
+			return err
+		}
+		containers = append(containers, container)
+		pluginVolumes := []corev1.Volume{{
+			Name:         pluginPrefix + plugin.Name + "-tmp",
+			VolumeSource: corev1.VolumeSource{EmptyDir: &corev1.EmptyDirVolumeSource{}},
+		}}
+		for _, volume := range append(pluginVolumes, plugin.Volumes...) {
+			u, err := toUnstructured(&volume)
+			if err != nil {
+				return err
+			}
+			podVolumes = append(podVolumes, u)
+		}
+	}
+	configMapVolume, err := toUnstructured(&corev1.Volume{
+		Name: pluginsVolume,
+		VolumeSource: corev1.VolumeSource{ConfigMap: &corev1.ConfigMapVolumeSource{
+			LocalObjectReference: corev1.LocalObjectReference{Name: pluginsConfigMap},
+		}},
+	})
+	if err != nil {
+		return err
+	}
+	podVolumes = append(podVolumes, configMapVolume)
+
+	if err := repoServer.MutatePodSpec(func(podSpec map[string]interface{}) error {
+		existingContainers, _ := podSpec["containers"].([]interface{})
+		podSpec["containers"] = append(existingContainers, containers...)
+		existingVolumes, _ := podSpec["volumes"].([]interface{})
+		podSpec["volumes"] = append(existingVolumes, podVolumes...)
+		return nil
+	}); err != nil {
+		return errors.Wrapf(err, "adding plugins to %s", repoServerWorkload)
+	}
+	return transforms.AddObject(objects, configMap)
+}
+
+// validatePlugins checks the plugins against each other and against the volumes of argocd-repo-server.
+func validatePlugins(plugins []addonsv1alpha1.ArgoCDPlugin, repoServerVolumes []string) error {
+	reserved := map[string]bool{pluginsVolume: true}
+	for _, name := range repoServerVolumes {
+		reserved[name] = true
+	}
+	names := map[string]bool{}
+	for i := range plugins {
+		plugin := &plugins[i]
+		if errs := validation.IsDNS1123Label(pluginPrefix + plugin.Name + "-tmp"); plugin.Name == "" || len(errs) != 0 {
+			return errors.Errorf("name %q of plugin %d must be a DNS label of at most 59 characters", plugin.Name, i)
+		}
+		if names[plugin.Name] {
+			return errors.Errorf("plugin %q is defined more than once", plugin.Name)
+		}
+		names[plugin.Name] = true
+		reserved[pluginPrefix+plugin.Name+"-tmp"] = true
+	}
+
+	// The volumes of all the plugins share the pod spec of argocd-repo-server.
+	declared := map[string]string{}
+	for i := range plugins {
+		plugin := &plugins[i]
+		if err := validatePlugin(plugin, reserved, declared); err != nil {
+			return errors.Wrapf(err, "plugin %q", plugin.Name)
+		}
+	}
+	return nil
+}
+
+// validatePlugin checks a plugin, and records its volumes in declared, by name, with the name of the plugin.
+func validatePlugin(plugin *addonsv1alpha1.ArgoCDPlugin, reserved map[string]bool, declared map[string]string) error {
+	if plugin.Image == "" {
+		return errors.New("image must be set")
+	}
+	if len(plugin.Generate.Command) == 0 {
+		return errors.New("generate.command must be set")
+	}
+	if plugin.Init != nil && len(plugin.Init.Command) == 0 {
+		return errors.New("init.command must be set")
+	}
+	if discover := plugin.Discover; discover != nil {
+		if (discover.FileName == "") == (discover.Find == nil) {
+			return errors.New("exactly one of discover.fileName and discover.find must be set")
+		}
+		if find := discover.Find; find != nil && (len(find.Command) == 0) == (find.Glob == "") {
+			return errors.New("exactly one of discover.find.command and discover.find.glob must be set")
+		}
+	}
+
+	volumes := map[string]bool{}
+	for name := range reserved {
+		volumes[name] = true
+	}
+	for _, volume := range plugin.Volumes {
+		if reserved[volume.Name] {
+			return errors.Errorf("volume %q conflicts with a volume of argocd-repo-server", volume.Name)
+		}
+		if other, ok := declared[volume.Name]; ok {
+			if other == plugin.Name {
+				return errors.Errorf("volume %q is declared more than once", volume.Name)
+			}
+			return errors.Errorf("volume %q conflicts with a volume of plugin %q", volume.Name, other)
+		}
+		declared[volume.Name] = plugin.Name
+		volumes[volume.Name] = true
+	}
+	for _, mount := range plugin.VolumeMounts {
+		if !volumes[mount.Name] {
+			return errors.Errorf("volumeMount %q references an unknown volume", mount.Name)
+		}
+		if isPluginPath(mount.MountPath) {
+			return errors.Errorf("volumeMount %q must not be mounted at %s, which is managed for the plugin", mount.Name, mount.MountPath)
+		}
+	}
+	return nil
+}
+
+// pluginConfig renders the plugin.yaml read by argocd-cmp-server.
+func pluginConfig(plugin *addonsv1alpha1.ArgoCDPlugin) (string, error) {
+	config := map[string]interface{}{
+		"apiVersion": "argoproj.io/v1alpha1",
+		"kind":       "ConfigManagementPlugin",
+		"metadata":   map[string]interface{}{"name": plugin.Name},
+		"spec": struct {
+			Version  string                                `json:"version,omitempty"`
+			Init     *addonsv1alpha1.ArgoCDPluginCommand   `json:"init,omitempty"`
+			Generate addonsv1alpha1.ArgoCDPluginCommand    `json:"generate"`
+			Discover *addonsv1alpha1.ArgoCDPluginDiscovery `json:"discover,omitempty"`
+		}{plugin.Version, plugin.Init, plugin.Generate, plugin.Discover},
+	}
+	b, err := yaml.Marshal(config)
+	if err != nil {
+		return "", err
+	}
+	return string(b), nil
+}
+
+// pluginContainer is the sidecar of the plugin, laid out as documented by ArgoCD.
+func pluginContainer(plugin *addonsv1alpha1.ArgoCDPlugin) *corev1.Container {
+	runAsNonRoot := true
+	runAsUser := argocdUser
+	container := &corev1.Container{
+		Name:    pluginPrefix + plugin.Name,
+		Image:   plugin.Image,
+		Command: []string{"/var/run/argocd/argocd-cmp-server"},
+		Env:     plugin.Env,
+		SecurityContext: &corev1.SecurityContext{
+			RunAsNonRoot: &runAsNonRoot,
+			RunAsUser:    &runAsUser,
+		},
+		VolumeMounts: append([]corev1.VolumeMount{
+			{Name: "var-files", MountPath: "/var/run/argocd"},
+			{Name: "plugins", MountPath: "/home/argocd/cmp-server/plugins"},
+			{Name: pluginsVolume, MountPath: "/home/argocd/cmp-server/config/plugin.yaml", SubPath: plugin.Name + ".yaml"},
+			{Name: pluginPrefix + plugin.Name + "-tmp", MountPath: "/tmp"},
+		}, plugin.VolumeMounts...),
+	}
+	if plugin.Resources != nil {
+		container.Resources = *plugin.Resources
+	}
+	return container
+}
+
+func isPluginPath(path string) bool {
+	path = strings.TrimSuffix(path, "/")
+	for _, managed := range []string{"/var/run/argocd", "/home/argocd/cmp-server", "/tmp"} {
+		if path == managed || strings.HasPrefix(path, managed+"/") {
+			return true
+		}
+	}
+	return false
+}
+
+func volumeNames(object *manifest.Object) ([]string, error) {
+	var names []string
+	err := object.MutatePodSpec(func(podSpec map[string]interface{}) error {
+		volumes, _ := podSpec["volumes"].([]interface{})
+		for _, volume := range volumes {
+			if volume, ok := volume.(map[string]interface{}); ok {
+				if name, ok := volume["name"].(string); ok {
+					names = append(names, name)
+				}
+			}
+		}
+		return nil
+	})
+	return names, err
+}
+
+func toUnstructured(object interface{}) (map[string]interface{}, error) {
+	u, err := runtime.DefaultUnstructuredConverter.ToUnstructured(object)
+	if err != nil {
+		return nil, errors.Wrapf(err, "converting %T", object)
+	}
+	return u, nil
+}
@@ -0,0 +1,132 @@
+package argocd
+
+import (
+	"strings"
+	"testing"
+
+	addonsv1alpha1 "github.com/yuwenma/moss/moss/api/v1alpha1"
+	corev1 "k8s.io/api/core/v1"
+)
+
+func TestValidatePlugins(t *testing.T) {
+	valid := func() addonsv1alpha1.ArgoCDPlugin {
+		return addonsv1alpha1.ArgoCDPlugin{
+			Name:     "cue",
+			Image:    "cuelang/cue:0.5.0",
+			Generate: addonsv1alpha1.ArgoCDPluginCommand{Command: []string{"cue", "export"}},
+		}
+	}
+	tests := []struct {
+		name    string
+		plugins func() []addonsv1alpha1.ArgoCDPlugin
+		want    string
+	}{
+		{
+			name: "valid",
+			plugins: func() []addonsv1alpha1.ArgoCDPlugin {
+				p := valid()
+				p.VolumeMounts = []corev1.VolumeMount{{Name: "tmp", MountPath: "/shared"}}
+				return []addonsv1alpha1.ArgoCDPlugin{p}
+			},
+		},
+		{
+			name: "invalid name",
+			plugins: func() []addonsv1alpha1.ArgoCDPlugin {
+				p := valid()
+				p.Name = "Cue"
+				return []addonsv1alpha1.ArgoCDPlugin{p}
+			},
+			want: `name "Cue" of plugin 0 must be a DNS label`,
+		},
+		{
+			name: "duplicate name",
+			plugins: func() []addonsv1alpha1.ArgoCDPlugin {
+				return []addonsv1alpha1.ArgoCDPlugin{valid(), valid()}
+			},
+			want: `plugin "cue" is defined more than once`,
+		},
+		{
+			name: "missing generate command",
+			plugins: func() []addonsv1alpha1.ArgoCDPlugin {
+				p := valid()
+				p.Generate.Command = nil
+				return []addonsv1alpha1.ArgoCDPlugin{p}
+			},
+			want: "generate.command must be set",
+		},
+		{
+			name: "ambiguous discovery",
+			plugins: func() []addonsv1alpha1.ArgoCDPlugin {
+				p := valid()
+				p.Discover = &addonsv1alpha1.ArgoCDPluginDiscovery{
+					FileName: "*.cue",
+					Find:     &addonsv1alpha1.ArgoCDPluginFind{Glob: "**/*.cue"},
+				}
+				return []addonsv1alpha1.ArgoCDPlugin{p}
+			},
+			want: "exactly one of discover.fileName and discover.find must be set",
+		},
+		{
+			name: "volume conflicts with repo-server",
+			plugins: func() []addonsv1alpha1.ArgoCDPlugin {
+				p := valid()
+				p.Volumes = []corev1.Volume{{Name: "tmp"}}
+				return []addonsv1alpha1.ArgoCDPlugin{p}
+			},
+			want: `volume "tmp" conflicts with a volume of argocd-repo-server`,
+		},
+		{
+			name: "volume declared twice",
+			plugins: func() []addonsv1alpha1.ArgoCDPlugin {
+				p := valid()
+				p.Volumes = []corev1.Volume{{Name: "cache"}, {Name: "cache"}}
+				return []addonsv1alpha1.ArgoCDPlugin{p}
+			},
+			want: `volume "cache" is declared more than once`,
+		},
+		{
+			name: "volume conflicts with another plugin",
+			plugins: func() []addonsv1alpha1.ArgoCDPlugin {
+				p, q := valid(), valid()
+				q.Name = "jsonnet"
+				p.Volumes = []corev1.Volume{{Name: "cache"}}
+				q.Volumes = []corev1.Volume{{Name: "cache"}}
+				return []addonsv1alpha1.ArgoCDPlugin{p, q}
+			},
+			want: `volume "cache" conflicts with a volume of plugin "cue"`,
+		},
+		{
+			name: "unknown volume",
+			plugins: func() []addonsv1alpha1.ArgoCDPlugin {
+				p := valid()
+				p.VolumeMounts = []corev1.VolumeMount{{Name: "cache", MountPath: "/cache"}}
+				return []addonsv1alpha1.ArgoCDPlugin{p}
+			},
+			want: `volumeMount "cache" references an unknown volume`,
+		},
+		{
+			name: "managed mount path",
+			plugins: func() []addonsv1alpha1.ArgoCDPlugin {
+				p := valid()
+				p.Volumes = []corev1.Volume{{Name: "cache"}}
+				p.VolumeMounts = []corev1.VolumeMount{{Name: "cache", MountPath: "/tmp/cache"}}
+				return []addonsv1alpha1.ArgoCDPlugin{p}
+			},
+			want: `volumeMount "cache" must not be mounted at /tmp/cache`,
+		},
+	}
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			err := validatePlugins(tc.plugins(), []string{"tmp", "var-files", "plugins"})
+			if tc.want == "" {
+				if err != nil {
+					t.Errorf("validatePlugins() error = %v, want nil", err)
+				}
+				return
+			}
+			if err == nil || !strings.Contains(err.Error(), tc.want) {
+				t.Errorf("validatePlugins() error = %v, want error containing %q", err, tc.want)
+			}
+		})
+	}
+}
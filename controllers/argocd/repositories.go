@@ -0,0 +1,188 @@
+package argocd
+
+import (
+	"context"
+
+	"github.com/pkg/errors"
+	addonsv1alpha1 "github.com/yuwenma/moss/moss/api/v1alpha1"
+	"github.com/yuwenma/moss/moss/pkg/transforms"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/apimachinery/pkg/util/validation/field"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/log"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+	"sigs.k8s.io/kubebuilder-declarative-pattern/pkg/patterns/declarative"
+	"sigs.k8s.io/kubebuilder-declarative-pattern/pkg/patterns/declarative/pkg/manifest"
+)
+
+const (
+	// secretTypeLabel tells ArgoCD how to interpret a Secret in its namespace.
+	secretTypeLabel      = "argocd.argoproj.io/secret-type"
+	secretTypeRepository = "repository"
+	secretTypeRepoCreds  = "repo-creds"
+)
+
+// reservedSecretNames are the Secrets of the argocd namespace which must not be replaced by a
+// repository Secret. The webhook checks them before the manifest is loaded, the reconciler checks the
+// Secrets of the manifest too.
+var reservedSecretNames = []string{"argocd-secret", "argocd-notifications-secret", "argocd-initial-admin-secret"}
+
+// addRepositorySecrets generates the repository and repo-creds Secrets of `spec.repositories` and
+// `spec.repositoryCredentialTemplates`, copying the credentials from the referenced Secrets.
+func (r *ArgoCDReconciler) addRepositorySecrets(ctx context.Context, o declarative.DeclarativeObject, objects *manifest.Objects) error {
+	argocd, ok := o.(*addonsv1alpha1.ArgoCD)
+	if !ok {
+		return errors.Errorf("expected ArgoCD object, got %T", o)
+	}
+	reserved := append([]string{}, reservedSecretNames...)
+	for _, object := range objects.Items {
+		if object.Kind == "Secret" && object.GetNamespace() == ArgoCDNamespace {
+			reserved = append(reserved, object.GetName())
+		}
+	}
+	if err := validateRepositoryNames(argocd, reserved).ToAggregate(); err != nil {
+		return err
+	}
+
+	for _, group := range []struct {
+		secretType   string
+		repositories []addonsv1alpha1.ArgoCDRepository
+	}{
+		{secretTypeRepository, argocd.Spec.Repositories},
+		{secretTypeRepoCreds, argocd.Spec.RepositoryCredentialTemplates},
+	} {
+		for _, repository := range group.repositories {
+			secret, err := r.repositorySecret(ctx, group.secretType, repository)
+			if err != nil {
+				return errors.Wrapf(err, "repository %q", repository.Name)
+			}
+			if err := transforms.AddObject(objects, secret); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// validateRepositoryNames checks that the Secrets generated for `spec.repositories` and
+// `spec.repositoryCredentialTemplates` have distinct names, and do not replace the reserved Secrets,
+// e.g. the Secrets of the manifest.
+func validateRepositoryNames(argocd *addonsv1alpha1.ArgoCD, reserved []string) field.ErrorList {
+	var errs field.ErrorList
+	names := map[string]bool{}
+	for _, name := range reserved {
+		names[name] = true
+	}
+	declared := map[string]bool{}
+	for _, group := range []struct {
+		path         *field.Path
+		repositories []addonsv1alpha1.ArgoCDRepository
+	}{
+		{field.NewPath("spec", "repositories"), argocd.Spec.Repositories},
+		{field.NewPath("spec", "repositoryCredentialTemplates"), argocd.Spec.RepositoryCredentialTemplates},
+	} {
+		for i, repository := range group.repositories {
+			path := group.path.Index(i).Child("name")
+			switch {
+			case names[repository.Name]:
+				errs = append(errs, field.Invalid(path, repository.Name, "the name of an ArgoCD Secret is reserved"))
+			case declared[repository.Name]:
+				errs = append(errs, field.Duplicate(path, repository.Name))
+			}
+			declared[repository.Name] = true
+		}
+	}
+	return errs
+}
+
+func (r *ArgoCDReconciler) repositorySecret(ctx context.Context, secretType string, repository addonsv1alpha1.ArgoCDRepository) (*corev1.Secret, error) {
+	repoType := repository.Type
+	if repoType == "" {
+		repoType = "git"
+	}
+	data := map[string][]byte{
+		"type": []byte(repoType),
+		"url":  []byte(repository.URL),
+	}
+	for key, ref := range map[string]*addonsv1alpha1.SecretKeyReference{
+		"username":      repository.Username,
+		"password":      repository.Password,
+		"sshPrivateKey": repository.SSHPrivateKey,
+	} {
+		if ref == nil {
+			continue
+		}
+		value, err := r.readSecretKey(ctx, ref)
+		if err != nil {
+			return nil, errors.Wrap(err, key)
+		}
+		data[key] = value
+	}
+
+	return &corev1.Secret{
+		TypeMeta: metav1.TypeMeta{APIVersion: "v1", Kind: "Secret"},
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      repository.Name,
+			Namespace: ArgoCDNamespace,
+			Labels: map[string]string{
+				secretTypeLabel: secretType,
+			},
+		},
+		Data: data,
+	}, nil
+}
+
+func (r *ArgoCDReconciler) readSecretKey(ctx context.Context, ref *addonsv1alpha1.SecretKeyReference) ([]byte, error) {
+	secret := &corev1.Secret{}
+	if err := r.apiReader.Get(ctx, types.NamespacedName{Namespace: ref.Namespace, Name: ref.Name}, secret); err != nil {
+		return nil, errors.Wrapf(err, "reading secret %s/%s", ref.Namespace, ref.Name)
+	}
+	value, ok := secret.Data[ref.Key]
+	if !ok {
+		return nil, errors.Errorf("key %q not found in secret %s/%s", ref.Key, ref.Namespace, ref.Name)
+	}
+	return value, nil
+}
+
+// secretReferenceIndex indexes the ArgoCD objects by the `<namespace>/<name>` of the Secrets they copy
+// credentials from.
+const secretReferenceIndex = "spec.repositories.secretRef"
+
+// argoCDsReferencingSecret maps a Secret to the ArgoCD objects copying credentials from it.
+func (r *ArgoCDReconciler) argoCDsReferencingSecret(o client.Object) []reconcile.Request {
+	ctx := context.Background()
+	list := &addonsv1alpha1.ArgoCDList{}
+	key := types.NamespacedName{Namespace: o.GetNamespace(), Name: o.GetName()}.String()
+	if err := r.Client.List(ctx, list, client.MatchingFields{secretReferenceIndex: key}); err != nil {
+		log.FromContext(ctx).Error(err, "listing ArgoCD objects")
+		return nil
+	}
+
+	var requests []reconcile.Request
+	for _, argocd := range list.Items {
+		requests = append(requests, reconcile.Request{NamespacedName: types.NamespacedName{Name: argocd.Name, Namespace: argocd.Namespace}})
+	}
+	return requests
+}
+
+// referencedSecrets is the indexer of secretReferenceIndex.
+func referencedSecrets(o client.Object) []string {
+	argocd, ok := o.(*addonsv1alpha1.ArgoCD)
+	if !ok {
+		return nil
+	}
+	var keys []string
+	repositories := append(append([]addonsv1alpha1.ArgoCDRepository{}, argocd.Spec.Repositories...), argocd.Spec.RepositoryCredentialTemplates...)
+	for _, repository := range repositories {
+		for _, ref := range []*addonsv1alpha1.SecretKeyReference{repository.Username, repository.Password, repository.SSHPrivateKey} {
+			if ref != nil {
+				keys = append(keys, types.NamespacedName{Namespace: ref.Namespace, Name: ref.Name}.String())
+			}
+		}
+	}
+	return keys
+}
+
+// +kubebuilder:rbac:groups="",resources=secrets,verbs=get;list;watch;create;update;delete;patch
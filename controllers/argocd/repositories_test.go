@@ -0,0 +1,97 @@
+package argocd
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	addonsv1alpha1 "github.com/yuwenma/moss/moss/api/v1alpha1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"sigs.k8s.io/kubebuilder-declarative-pattern/pkg/patterns/declarative/pkg/manifest"
+)
+
+func TestValidateRepositoryNames(t *testing.T) {
+	repository := func(name string) addonsv1alpha1.ArgoCDRepository {
+		return addonsv1alpha1.ArgoCDRepository{Name: name, URL: "https://github.com/example/" + name}
+	}
+	tests := []struct {
+		name      string
+		repos     []addonsv1alpha1.ArgoCDRepository
+		templates []addonsv1alpha1.ArgoCDRepository
+		want      string
+	}{
+		{
+			name:      "distinct",
+			repos:     []addonsv1alpha1.ArgoCDRepository{repository("apps"), repository("infra")},
+			templates: []addonsv1alpha1.ArgoCDRepository{repository("example")},
+		},
+		{
+			name:  "duplicate repository",
+			repos: []addonsv1alpha1.ArgoCDRepository{repository("apps"), repository("apps")},
+			want:  `spec.repositories[1].name: Duplicate value: "apps"`,
+		},
+		{
+			name:      "repository and template",
+			repos:     []addonsv1alpha1.ArgoCDRepository{repository("apps")},
+			templates: []addonsv1alpha1.ArgoCDRepository{repository("apps")},
+			want:      `spec.repositoryCredentialTemplates[0].name: Duplicate value: "apps"`,
+		},
+		{
+			name:  "manifest Secret",
+			repos: []addonsv1alpha1.ArgoCDRepository{repository("argocd-secret")},
+			want:  `spec.repositories[0].name: Invalid value: "argocd-secret": the name of an ArgoCD Secret is reserved`,
+		},
+	}
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			argocd := &addonsv1alpha1.ArgoCD{}
+			argocd.Spec.Repositories = tc.repos
+			argocd.Spec.RepositoryCredentialTemplates = tc.templates
+			err := validateRepositoryNames(argocd, reservedSecretNames).ToAggregate()
+			if tc.want == "" {
+				if err != nil {
+					t.Errorf("validateRepositoryNames() error = %v", err)
+				}
+				return
+			}
+			if err == nil || !strings.Contains(err.Error(), tc.want) {
+				t.Errorf("validateRepositoryNames() error = %v, want %q", err, tc.want)
+			}
+		})
+	}
+}
+
+func TestAddRepositorySecretsManifestSecret(t *testing.T) {
+	ctx := context.Background()
+	objects, err := manifest.ParseObjects(ctx, "apiVersion: v1\nkind: Secret\nmetadata:\n  name: argocd-dex-secret\n  namespace: argocd\n")
+	if err != nil {
+		t.Fatal(err)
+	}
+	argocd := &addonsv1alpha1.ArgoCD{}
+	argocd.Spec.Repositories = []addonsv1alpha1.ArgoCDRepository{{Name: "argocd-dex-secret", URL: "https://github.com/example/apps"}}
+	r := &ArgoCDReconciler{}
+	if err := r.addRepositorySecrets(ctx, argocd, objects); err == nil || !strings.Contains(err.Error(), "reserved") {
+		t.Errorf("addRepositorySecrets() error = %v, want the Secret of the manifest to be reserved", err)
+	}
+	if len(objects.Items) != 1 {
+		t.Errorf("addRepositorySecrets() added %d objects, want none", len(objects.Items)-1)
+	}
+}
+
+func TestArgoCDValidator(t *testing.T) {
+	v := &argoCDValidator{}
+	argocd := &addonsv1alpha1.ArgoCD{}
+	argocd.Name = "argocd-sample"
+	argocd.Spec.Repositories = []addonsv1alpha1.ArgoCDRepository{{Name: "apps"}, {Name: "apps"}}
+	if err := v.ValidateCreate(context.Background(), argocd); !apierrors.IsInvalid(err) {
+		t.Errorf("ValidateCreate() error = %v, want an Invalid error", err)
+	}
+	if err := v.ValidateUpdate(context.Background(), &addonsv1alpha1.ArgoCD{}, argocd); !apierrors.IsInvalid(err) {
+		t.Errorf("ValidateUpdate() error = %v, want an Invalid error", err)
+	}
+
+	argocd.Spec.Repositories = argocd.Spec.Repositories[:1]
+	if err := v.ValidateCreate(context.Background(), argocd); err != nil {
+		t.Errorf("ValidateCreate() error = %v", err)
+	}
+}
@@ -0,0 +1,71 @@
+package argocd
+
+import (
+	"context"
+	"strings"
+
+	"github.com/pkg/errors"
+	addonsv1alpha1 "github.com/yuwenma/moss/moss/api/v1alpha1"
+	"github.com/yuwenma/moss/moss/pkg/transforms"
+	"sigs.k8s.io/kubebuilder-declarative-pattern/pkg/patterns/declarative"
+	"sigs.k8s.io/kubebuilder-declarative-pattern/pkg/patterns/declarative/pkg/manifest"
+	"sigs.k8s.io/yaml"
+)
+
+const (
+	settingsConfigMap = "argocd-cm"
+	rbacConfigMap     = "argocd-rbac-cm"
+)
+
+// applySettings renders `spec.settings` into argocd-cm and `spec.rbac` into argocd-rbac-cm.
+// Keys whose field is unset are left out, so that removing a field from the spec removes the key.
+func applySettings(ctx context.Context, o declarative.DeclarativeObject, objects *manifest.Objects) error {
+	argocd, ok := o.(*addonsv1alpha1.ArgoCD)
+	if !ok {
+		return errors.Errorf("expected ArgoCD object, got %T", o)
+	}
+	settings, rbac := argocd.Spec.Settings, argocd.Spec.RBAC
+
+	data := map[string]string{}
+	for _, field := range []struct {
+		key, path, value string
+		isYAML           bool
+	}{
+		{"url", "spec.settings.url", settings.URL, false},
+		{"oidc.config", "spec.settings.oidcConfig", settings.OIDCConfig, true},
+		{"resource.customizations", "spec.settings.resourceCustomizations", settings.ResourceCustomizations, true},
+		{"resource.exclusions", "spec.settings.resourceExclusions", settings.ResourceExclusions, true},
+	} {
+		if field.value == "" {
+			continue
+		}
+		if field.isYAML {
+			var v interface{}
+			if err := yaml.Unmarshal([]byte(field.value), &v); err != nil {
+				return errors.Wrapf(err, "%s is not valid YAML", field.path)
+			}
+		}
+		data[field.key] = field.value
+	}
+	if settings.ReconciliationTimeout != nil {
+		if settings.ReconciliationTimeout.Duration < 0 {
+			return errors.Errorf("spec.settings.reconciliationTimeout must not be negative, got %s", settings.ReconciliationTimeout.Duration)
+		}
+		data["timeout.reconciliation"] = settings.ReconciliationTimeout.Duration.String()
+	}
+	if err := transforms.SetConfigMapData(objects, settingsConfigMap, data); err != nil {
+		return err
+	}
+
+	rbacData := map[string]string{}
+	if rbac.PolicyCSV != "" {
+		rbacData["policy.csv"] = rbac.PolicyCSV
+	}
+	if rbac.DefaultPolicy != "" {
+		rbacData["policy.default"] = rbac.DefaultPolicy
+	}
+	if len(rbac.Scopes) != 0 {
+		rbacData["scopes"] = "[" + strings.Join(rbac.Scopes, ", ") + "]"
+	}
+	return transforms.SetConfigMapData(objects, rbacConfigMap, rbacData)
+}
@@ -0,0 +1,42 @@
+package argocd
+
+import (
+	"context"
+
+	"github.com/pkg/errors"
+	addonsv1alpha1 "github.com/yuwenma/moss/moss/api/v1alpha1"
+	mossstatus "github.com/yuwenma/moss/moss/pkg/status"
+	"sigs.k8s.io/kubebuilder-declarative-pattern/pkg/patterns/declarative"
+)
+
+// buildStatus sets the ArgoCD specific fields of the status.
+func (r *ArgoCDReconciler) buildStatus(ctx context.Context, info *declarative.StatusInfo) error {
+	argocd, ok := info.Subject.(*addonsv1alpha1.ArgoCD)
+	if !ok {
+		return errors.Errorf("expected ArgoCD object, got %T", info.Subject)
+	}
+
+	url, err := externalURL(ctx, argocd, info)
+	if err != nil {
+		return err
+	}
+	argocd.Status.ExternalURL = url
+
+	components, applied, err := mossstatus.Components(ctx, info)
+	if err != nil {
+		return err
+	}
+	if applied {
+		argocd.Status.Components = components
+	}
+
+	if deployed := mossstatus.DeployedPackage(info, r.loader); deployed != nil {
+		argocd.Status.DeployedPackage = *deployed
+	}
+	argocd.Status.AvailableVersion = mossstatus.AvailableVersion(info, r.loader)
+	mossstatus.Rollback(info, r.loader, argocd.Status.Healthy, &argocd.Status.RollbackStatus, &argocd.Status.Conditions)
+	if inventory := mossstatus.Inventory(info); inventory != nil {
+		argocd.Status.Inventory = inventory
+	}
+	return nil
+}
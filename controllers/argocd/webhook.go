@@ -0,0 +1,55 @@
+package argocd
+
+import (
+	"context"
+
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/runtime"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/webhook/admission"
+
+	addonsv1alpha1 "github.com/yuwenma/moss/moss/api/v1alpha1"
+	"github.com/yuwenma/moss/moss/pkg/webhooks"
+)
+
+//+kubebuilder:webhook:path=/validate-configdelivery-anthos-io-v1alpha1-argocd,mutating=false,failurePolicy=fail,sideEffects=None,groups=configdelivery.anthos.io,resources=argocds,verbs=create;update,versions=v1alpha1,name=vargocd.configdelivery.anthos.io,admissionReviewVersions=v1
+
+// SetupWebhookWithManager registers the webhook validating the names of the repository Secrets, and the
+// changes of spec.version against the upgrade paths of the ArgoCD packages. It must be called after
+// SetupWithManager.
+func (r *ArgoCDReconciler) SetupWebhookWithManager(mgr ctrl.Manager) error {
+	return ctrl.NewWebhookManagedBy(mgr).
+		For(&addonsv1alpha1.ArgoCD{}).
+		WithValidator(&argoCDValidator{UpgradeValidator: webhooks.UpgradeValidator{Loader: r.loader}}).
+		Complete()
+}
+
+// argoCDValidator rejects the ArgoCD objects whose repository Secrets would collide, on top of the
+// upgrade paths checked by UpgradeValidator.
+type argoCDValidator struct {
+	webhooks.UpgradeValidator
+}
+
+var _ admission.CustomValidator = &argoCDValidator{}
+
+func (v *argoCDValidator) ValidateCreate(ctx context.Context, obj runtime.Object) error {
+	return validateArgoCD(obj)
+}
+
+func (v *argoCDValidator) ValidateUpdate(ctx context.Context, oldObj, newObj runtime.Object) error {
+	if err := validateArgoCD(newObj); err != nil {
+		return err
+	}
+	return v.UpgradeValidator.ValidateUpdate(ctx, oldObj, newObj)
+}
+
+func validateArgoCD(obj runtime.Object) error {
+	argocd, ok := obj.(*addonsv1alpha1.ArgoCD)
+	if !ok {
+		return apierrors.NewBadRequest("expected an ArgoCD object")
+	}
+	if errs := validateRepositoryNames(argocd, reservedSecretNames); len(errs) != 0 {
+		return apierrors.NewInvalid(addonsv1alpha1.GroupVersion.WithKind("ArgoCD").GroupKind(), argocd.Name, errs)
+	}
+	return nil
+}
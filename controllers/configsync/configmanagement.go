@@ -0,0 +1,91 @@
+package configsync
+
+import (
+	"context"
+	"fmt"
+
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/log"
+	"sigs.k8s.io/kubebuilder-declarative-pattern/pkg/patterns/declarative"
+	"sigs.k8s.io/kubebuilder-declarative-pattern/pkg/patterns/declarative/pkg/manifest"
+
+	addonsv1alpha1 "github.com/yuwenma/moss/moss/api/v1alpha1"
+	mossstatus "github.com/yuwenma/moss/moss/pkg/status"
+	"github.com/yuwenma/moss/moss/pkg/transforms"
+)
+
+const (
+	configManagementName = "config-management"
+
+	// configManagementCRD is shipped in the manifest.
+	configManagementCRD = "configmanagements.configmanagement.gke.io"
+)
+
+var configManagementGVK = schema.GroupVersionKind{Group: "configmanagement.gke.io", Version: "v1", Kind: "ConfigManagement"}
+
+// addConfigManagement adds the ConfigManagement rendered from the spec, once the operator is ready to
+// reconcile it. The ConfigManagement enables multi-repo mode, which makes the operator install the RootSync
+// and RepoSync CRDs. It is applied on every reconcile, correcting the changes made to it by hand.
+func (r *ConfigSyncReconciler) addConfigManagement(ctx context.Context, o declarative.DeclarativeObject, objects *manifest.Objects) error {
+	configSync, ok := o.(*addonsv1alpha1.ConfigSync)
+	if !ok {
+		return fmt.Errorf("expected ConfigSync object, got %T", o)
+	}
+
+	ready, reason, err := r.operatorReady(ctx)
+	if err != nil {
+		return err
+	}
+	if !ready {
+		log.FromContext(ctx).Info("waiting to apply the ConfigManagement", "reason", reason)
+	}
+	return transforms.AddGated(ctx, r.apiReader, objects, ready, []*unstructured.Unstructured{configManagementObject(&configSync.Spec)})
+}
+
+// operatorReady reports whether the config-management-operator can reconcile a ConfigManagement.
+func (r *ConfigSyncReconciler) operatorReady(ctx context.Context) (bool, string, error) {
+	ready, reason, err := mossstatus.IsCurrent(ctx, r.apiReader, mossstatus.CustomResourceDefinitionGVK, types.NamespacedName{Name: configManagementCRD})
+	if err != nil || !ready {
+		return false, reason, err
+	}
+	gvk := schema.GroupVersionKind{Group: "apps", Version: "v1", Kind: operatorWorkload.Kind}
+	return mossstatus.IsCurrent(ctx, r.apiReader, gvk, types.NamespacedName{Namespace: configSyncNamespace, Name: operatorWorkload.Name})
+}
+
+func configManagementObject(spec *addonsv1alpha1.ConfigSyncSpec) *unstructured.Unstructured {
+	configManagementSpec := map[string]interface{}{
+		"enableMultiRepo": true,
+		"preventDrift":    spec.PreventDrift,
+	}
+	if pc := spec.PolicyController; pc != nil {
+		policyController := map[string]interface{}{
+			"enabled":                 pc.Enabled,
+			"referentialRulesEnabled": pc.ReferentialRulesEnabled,
+			"logDeniesEnabled":        pc.LogDeniesEnabled,
+			"mutation":                map[string]interface{}{"enabled": pc.MutationEnabled},
+		}
+		if pc.TemplateLibraryInstalled != nil {
+			policyController["templateLibraryInstalled"] = *pc.TemplateLibraryInstalled
+		}
+		if len(pc.ExemptableNamespaces) != 0 {
+			namespaces := make([]interface{}, 0, len(pc.ExemptableNamespaces))
+			for _, ns := range pc.ExemptableNamespaces {
+				namespaces = append(namespaces, ns)
+			}
+			policyController["exemptableNamespaces"] = namespaces
+		}
+		if pc.AuditIntervalSeconds != nil {
+			policyController["auditIntervalSeconds"] = *pc.AuditIntervalSeconds
+		}
+		configManagementSpec["policyController"] = policyController
+	}
+
+	u := &unstructured.Unstructured{Object: map[string]interface{}{"spec": configManagementSpec}}
+	u.SetGroupVersionKind(configManagementGVK)
+	u.SetName(configManagementName)
+	return u
+}
+
+// +kubebuilder:rbac:groups=configmanagement.gke.io,resources=configmanagements,verbs=get;list;watch;create;update;delete;patch
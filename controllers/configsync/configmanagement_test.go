@@ -0,0 +1,63 @@
+package configsync
+
+import (
+	"reflect"
+	"testing"
+
+	addonsv1alpha1 "github.com/yuwenma/moss/moss/api/v1alpha1"
+)
+
+func TestConfigManagementObject(t *testing.T) {
+	templateLibraryInstalled := false
+	auditIntervalSeconds := int64(0)
+	tests := []struct {
+		name string
+		spec addonsv1alpha1.ConfigSyncSpec
+		want map[string]interface{}
+	}{
+		{
+			name: "default",
+			want: map[string]interface{}{
+				"enableMultiRepo": true,
+				"preventDrift":    false,
+			},
+		},
+		{
+			name: "policy controller and drift prevention",
+			spec: addonsv1alpha1.ConfigSyncSpec{
+				PreventDrift: true,
+				PolicyController: &addonsv1alpha1.PolicyController{
+					Enabled:                  true,
+					TemplateLibraryInstalled: &templateLibraryInstalled,
+					MutationEnabled:          true,
+					ExemptableNamespaces:     []string{"kube-system"},
+					AuditIntervalSeconds:     &auditIntervalSeconds,
+				},
+			},
+			want: map[string]interface{}{
+				"enableMultiRepo": true,
+				"preventDrift":    true,
+				"policyController": map[string]interface{}{
+					"enabled":                  true,
+					"templateLibraryInstalled": false,
+					"referentialRulesEnabled":  false,
+					"logDeniesEnabled":         false,
+					"mutation":                 map[string]interface{}{"enabled": true},
+					"exemptableNamespaces":     []interface{}{"kube-system"},
+					"auditIntervalSeconds":     int64(0),
+				},
+			},
+		},
+	}
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			got := configManagementObject(&tc.spec)
+			if got.GetName() != configManagementName {
+				t.Errorf("name = %s, want %s", got.GetName(), configManagementName)
+			}
+			if !reflect.DeepEqual(got.Object["spec"], tc.want) {
+				t.Errorf("spec = %v, want %v", got.Object["spec"], tc.want)
+			}
+		})
+	}
+}
@@ -1,8 +1,17 @@
 package configsync
 
 import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+
 	"github.com/go-logr/logr"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/types"
 	ctrl "sigs.k8s.io/controller-runtime"
 	"sigs.k8s.io/controller-runtime/pkg/client"
 	"sigs.k8s.io/controller-runtime/pkg/controller"
@@ -10,10 +19,15 @@ import (
 	"sigs.k8s.io/controller-runtime/pkg/reconcile"
 	"sigs.k8s.io/controller-runtime/pkg/source"
 	"sigs.k8s.io/kubebuilder-declarative-pattern/pkg/patterns/addon"
+	addonloaders "sigs.k8s.io/kubebuilder-declarative-pattern/pkg/patterns/addon/pkg/loaders"
 	"sigs.k8s.io/kubebuilder-declarative-pattern/pkg/patterns/addon/pkg/status"
 	"sigs.k8s.io/kubebuilder-declarative-pattern/pkg/patterns/declarative"
+	"sigs.k8s.io/kubebuilder-declarative-pattern/pkg/patterns/declarative/pkg/applier"
 
 	addonsv1alpha1 "github.com/yuwenma/moss/moss/api/v1alpha1"
+	"github.com/yuwenma/moss/moss/pkg/loaders"
+	mossstatus "github.com/yuwenma/moss/moss/pkg/status"
+	"github.com/yuwenma/moss/moss/pkg/transforms"
 )
 
 var _ reconcile.Reconciler = &ConfigSyncReconciler{}
@@ -24,27 +38,66 @@ type ConfigSyncReconciler struct {
 	Log    logr.Logger
 	Scheme *runtime.Scheme
 
+	// apiReader reads the objects the manifest depends on, bypassing the cache.
+	apiReader client.Reader
+	// loader loads the configsync package, and records the version it resolved.
+	loader *loaders.ManifestLoader
+
+	// controller watches the RootSyncs and RepoSyncs, once their CRDs are installed.
+	controller   controller.Controller
+	watchesMutex sync.Mutex
+	syncWatches  map[schema.GroupVersionKind]bool
+
 	declarative.Reconciler
 }
 
 //+kubebuilder:rbac:groups=configdelivery.anthos.io,resources=configsyncs,verbs=get;list;watch;create;update;patch;delete
 //+kubebuilder:rbac:groups=configdelivery.anthos.io,resources=configsyncs/status,verbs=get;update;patch
+//+kubebuilder:rbac:groups="",resources=events,verbs=create;patch
 
 // SetupWithManager sets up the controller with the Manager.
 func (r *ConfigSyncReconciler) SetupWithManager(mgr ctrl.Manager) error {
 	addon.Init()
+	r.apiReader = mgr.GetAPIReader()
+	r.syncWatches = map[schema.GroupVersionKind]bool{}
 
 	labels := map[string]string{
 		"k8s-app": "configsync",
 	}
 
+	serverVersion, err := loaders.DiscoveryServerVersion(mgr.GetConfig())
+	if err != nil {
+		return err
+	}
+	loader, err := loaders.NewManifestLoader(addonloaders.FlagChannel, nil, serverVersion)
+	if err != nil {
+		return err
+	}
+	r.loader = loader
+
+	applier := applier.NewApplySetApplier(metav1.PatchOptions{}, metav1.DeleteOptions{}, applier.ApplysetOptions{})
 	watchLabels := declarative.SourceLabel(mgr.GetScheme())
 	if err := r.Reconciler.Init(mgr, &addonsv1alpha1.ConfigSync{},
+		declarative.WithManifestController(loader),
+		// Only the images of the package are pulled from the internal registry, they are the images
+		// bundled for air-gapped clusters.
+		declarative.WithObjectTransform(transforms.RewriteImages),
+		// Transforms adding objects run before the labels are added.
+		declarative.WithObjectTransform(r.addConfigManagement),
+		declarative.WithObjectTransform(r.addRootSync),
 		declarative.WithObjectTransform(declarative.AddLabels(labels)),
 		declarative.WithOwner(declarative.SourceAsOwner),
 		declarative.WithLabels(watchLabels),
-		declarative.WithStatus(status.NewBasic(mgr.GetClient())),
+		declarative.WithStatus(mossstatus.WithEvents(mgr.GetEventRecorderFor("configsync-controller"),
+			mossstatus.WithAddonStatus(mgr.GetClient(),
+				mossstatus.WithReconcileErrors(status.NewKstatusCheck(mgr.GetClient(), &r.Reconciler)),
+				r.buildStatus,
+			),
+		)),
+		declarative.WithObjectTransform(applyOperatorSettings),
 		declarative.WithObjectTransform(addon.ApplyPatches),
+		declarative.WithApplier(applier),
+		declarative.WithApplyPrune(),
 	); err != nil {
 		return err
 	}
@@ -53,6 +106,7 @@ func (r *ConfigSyncReconciler) SetupWithManager(mgr ctrl.Manager) error {
 	if err != nil {
 		return err
 	}
+	r.controller = c
 
 	// Watch for changes to ConfigSync
 	err = c.Watch(&source.Kind{Type: &addonsv1alpha1.ConfigSync{}}, &handler.EnqueueRequestForObject{})
@@ -74,5 +128,43 @@ func (r *ConfigSyncReconciler) SetupWithManager(mgr ctrl.Manager) error {
 		return err
 	}
 
+	// Watch for the CRDs installed by the operator
+	crd := &metav1.PartialObjectMetadata{}
+	crd.SetGroupVersionKind(mossstatus.CustomResourceDefinitionGVK)
+	err = c.Watch(&source.Kind{Type: crd}, handler.EnqueueRequestsFromMapFunc(r.configSyncsForCRD))
+	if err != nil {
+		return err
+	}
+
 	return nil
 }
+
+// Reconcile reconciles the ConfigSync object, and requeues it to pick up the new versions of its channel.
+func (r *ConfigSyncReconciler) Reconcile(ctx context.Context, req reconcile.Request) (reconcile.Result, error) {
+	result, err := r.Reconciler.Reconcile(ctx, req)
+	if err != nil || result.Requeue || result.RequeueAfter != 0 {
+		return result, err
+	}
+	deleted, err := r.deleted(ctx, req.NamespacedName)
+	if err != nil {
+		return result, err
+	}
+	if deleted {
+		r.loader.Forget(req.NamespacedName)
+		return result, nil
+	}
+	result.RequeueAfter = r.loader.RequeueAfter(req.NamespacedName, time.Now())
+	return result, nil
+}
+
+// deleted returns whether the ConfigSync object key is gone or being deleted, so that it is no longer requeued.
+func (r *ConfigSyncReconciler) deleted(ctx context.Context, key types.NamespacedName) (bool, error) {
+	o := &addonsv1alpha1.ConfigSync{}
+	if err := r.Client.Get(ctx, key, o); err != nil {
+		if apierrors.IsNotFound(err) {
+			return true, nil
+		}
+		return false, fmt.Errorf("error reading ConfigSync %s: %w", key, err)
+	}
+	return !o.GetDeletionTimestamp().IsZero(), nil
+}
@@ -0,0 +1,30 @@
+package configsync
+
+import (
+	"testing"
+
+	clientgoscheme "k8s.io/client-go/kubernetes/scheme"
+	"sigs.k8s.io/controller-runtime/pkg/scheme"
+	"sigs.k8s.io/kubebuilder-declarative-pattern/pkg/test/golden"
+
+	addonsv1alpha1 "github.com/yuwenma/moss/moss/api/v1alpha1"
+	"github.com/yuwenma/moss/moss/pkg/testutil"
+)
+
+func TestConfigSyncController(t *testing.T) {
+	schemeBuilder := &scheme.Builder{}
+	schemeBuilder.SchemeBuilder.Register(clientgoscheme.AddToScheme, addonsv1alpha1.AddToScheme)
+	validator := golden.NewValidator(t, schemeBuilder)
+	dr := &ConfigSyncReconciler{
+		Client: validator.Client(),
+	}
+	err := dr.SetupWithManager(validator.Manager())
+	if err != nil {
+		t.Fatalf("creating reconciler: %v", err)
+	}
+
+	// The operator is ready and installed the RootSync CRD, so that the ConfigManagement and spec.rootSync
+	// are rendered.
+	testutil.CreateObjects(t, validator.Client(), "tests/installed.yaml")
+	validator.Validate(dr.Reconciler)
+}
@@ -0,0 +1,27 @@
+package configsync
+
+import (
+	"context"
+	"fmt"
+
+	"sigs.k8s.io/kubebuilder-declarative-pattern/pkg/patterns/declarative"
+	"sigs.k8s.io/kubebuilder-declarative-pattern/pkg/patterns/declarative/pkg/manifest"
+
+	addonsv1alpha1 "github.com/yuwenma/moss/moss/api/v1alpha1"
+	"github.com/yuwenma/moss/moss/pkg/transforms"
+)
+
+var operatorWorkload = transforms.Workload{Kind: "Deployment", Name: "config-management-operator", Container: "manager"}
+
+// applyOperatorSettings writes the replicas, resources and scheduling settings of `spec.operator`
+// into the config-management-operator Deployment.
+func applyOperatorSettings(ctx context.Context, o declarative.DeclarativeObject, objects *manifest.Objects) error {
+	configSync, ok := o.(*addonsv1alpha1.ConfigSync)
+	if !ok {
+		return fmt.Errorf("expected ConfigSync object, got %T", o)
+	}
+	if err := transforms.ApplyWorkloadSpec(objects, operatorWorkload, configSync.Spec.Operator); err != nil {
+		return fmt.Errorf("spec.operator: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,106 @@
+package configsync
+
+import (
+	"context"
+	"fmt"
+
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/log"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+	"sigs.k8s.io/kubebuilder-declarative-pattern/pkg/patterns/declarative"
+	"sigs.k8s.io/kubebuilder-declarative-pattern/pkg/patterns/declarative/pkg/manifest"
+
+	addonsv1alpha1 "github.com/yuwenma/moss/moss/api/v1alpha1"
+	mossstatus "github.com/yuwenma/moss/moss/pkg/status"
+	"github.com/yuwenma/moss/moss/pkg/transforms"
+)
+
+const (
+	configSyncNamespace = "config-management-system"
+	rootSyncName        = "root-sync"
+
+	// rootSyncCRD is installed by the operator once multi-repo mode is enabled.
+	rootSyncCRD = "rootsyncs.configsync.gke.io"
+
+	// requiresRenderingAnnotation tells the reconciler-manager whether the reconciler of a RootSync runs the
+	// hydration-controller.
+	requiresRenderingAnnotation = "configsync.gke.io/requires-rendering"
+)
+
+var rootSyncGVK = schema.GroupVersionKind{Group: "configsync.gke.io", Version: "v1beta1", Kind: "RootSync"}
+
+// addRootSync adds the RootSync of `spec.rootSync` once the operator installed the RootSync CRD.
+func (r *ConfigSyncReconciler) addRootSync(ctx context.Context, o declarative.DeclarativeObject, objects *manifest.Objects) error {
+	configSync, ok := o.(*addonsv1alpha1.ConfigSync)
+	if !ok {
+		return fmt.Errorf("expected ConfigSync object, got %T", o)
+	}
+	if configSync.Spec.RootSync == nil {
+		return nil
+	}
+	rootSync, err := rootSyncObject(configSync.Spec.RootSync)
+	if err != nil {
+		return fmt.Errorf("spec.rootSync: %w", err)
+	}
+	if hydration := configSync.Spec.HydrationController; hydration != nil {
+		rootSync.SetAnnotations(map[string]string{requiresRenderingAnnotation: fmt.Sprint(hydration.Enabled)})
+	}
+
+	ready, reason, err := r.operatorReady(ctx)
+	if err != nil {
+		return err
+	}
+	if ready {
+		ready, reason, err = mossstatus.IsCurrent(ctx, r.apiReader, mossstatus.CustomResourceDefinitionGVK, types.NamespacedName{Name: rootSyncCRD})
+		if err != nil {
+			return err
+		}
+	}
+	if !ready {
+		log.FromContext(ctx).Info("waiting to apply spec.rootSync", "reason", reason)
+	}
+	return transforms.AddGated(ctx, r.apiReader, objects, ready, []*unstructured.Unstructured{rootSync})
+}
+
+// rootSyncObject validates spec and builds the RootSync.
+func rootSyncObject(spec *addonsv1alpha1.SyncSource) (*unstructured.Unstructured, error) {
+	rootSyncSpec, err := syncSpec(spec)
+	if err != nil {
+		return nil, err
+	}
+	u := &unstructured.Unstructured{Object: map[string]interface{}{"spec": rootSyncSpec}}
+	u.SetGroupVersionKind(rootSyncGVK)
+	u.SetNamespace(configSyncNamespace)
+	u.SetName(rootSyncName)
+	return u, nil
+}
+
+// configSyncsForCRD maps the CRDs installed by the operator to the ConfigSync objects, which wait for
+// them to be established before applying or watching their custom resources.
+func (r *ConfigSyncReconciler) configSyncsForCRD(o client.Object) []reconcile.Request {
+	if o.GetName() != rootSyncCRD && o.GetName() != repoSyncCRD {
+		return nil
+	}
+	return r.allConfigSyncs(o)
+}
+
+// allConfigSyncs maps any object to all the ConfigSync objects.
+func (r *ConfigSyncReconciler) allConfigSyncs(client.Object) []reconcile.Request {
+	ctx := context.Background()
+	list := &addonsv1alpha1.ConfigSyncList{}
+	if err := r.Client.List(ctx, list); err != nil {
+		log.FromContext(ctx).Error(err, "listing ConfigSync objects")
+		return nil
+	}
+	var requests []reconcile.Request
+	for _, configSync := range list.Items {
+		requests = append(requests, reconcile.Request{NamespacedName: types.NamespacedName{Name: configSync.Name}})
+	}
+	return requests
+}
+
+// +kubebuilder:rbac:groups=configsync.gke.io,resources=rootsyncs,verbs=get;list;watch;create;update;delete;patch
+// +kubebuilder:rbac:groups=apiextensions.k8s.io,resources=customresourcedefinitions,verbs=get;list;watch
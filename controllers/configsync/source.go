@@ -0,0 +1,135 @@
+package configsync
+
+import (
+	"fmt"
+	"strings"
+
+	addonsv1alpha1 "github.com/yuwenma/moss/moss/api/v1alpha1"
+)
+
+// authTypes are the auth types supported by each source type.
+var authTypes = map[addonsv1alpha1.SourceType][]string{
+	addonsv1alpha1.SourceTypeGit:  {"none", "ssh", "cookiefile", "gcenode", "token", "gcpserviceaccount"},
+	addonsv1alpha1.SourceTypeOCI:  {"none", "gcenode", "gcpserviceaccount", "k8sserviceaccount"},
+	addonsv1alpha1.SourceTypeHelm: {"none", "token", "gcenode", "gcpserviceaccount", "k8sserviceaccount"},
+}
+
+// syncSpec validates spec and builds the spec of the RootSync or RepoSync syncing from it.
+func syncSpec(spec *addonsv1alpha1.SyncSource) (map[string]interface{}, error) {
+	if err := validateSyncSource(spec); err != nil {
+		return nil, err
+	}
+	sourceType := sourceTypeOf(spec)
+
+	source := map[string]interface{}{}
+	set := func(key, value string) {
+		if value != "" {
+			source[key] = value
+		}
+	}
+	set("auth", spec.Auth)
+	set("gcpServiceAccountEmail", spec.GCPServiceAccountEmail)
+	if spec.SecretRef != "" {
+		source["secretRef"] = map[string]interface{}{"name": spec.SecretRef}
+	}
+	if spec.Period != nil {
+		source["period"] = spec.Period.Duration.String()
+	}
+	switch sourceType {
+	case addonsv1alpha1.SourceTypeGit:
+		set("repo", spec.Repo)
+		set("branch", spec.Branch)
+		set("revision", spec.Revision)
+		set("dir", spec.Dir)
+	case addonsv1alpha1.SourceTypeOCI:
+		set("image", ociImage(spec.Repo, spec.Revision))
+		set("dir", spec.Dir)
+	case addonsv1alpha1.SourceTypeHelm:
+		set("repo", spec.Repo)
+		set("chart", spec.Chart)
+		set("version", spec.Revision)
+	}
+
+	syncSpec := map[string]interface{}{
+		"sourceType":       string(sourceType),
+		string(sourceType): source,
+	}
+	if spec.SourceFormat != "" {
+		syncSpec["sourceFormat"] = spec.SourceFormat
+	}
+	return syncSpec, nil
+}
+
+// validateSyncSource checks the fields supported by the source type, which the schema can not express.
+func validateSyncSource(spec *addonsv1alpha1.SyncSource) error {
+	sourceType := sourceTypeOf(spec)
+	if spec.Repo == "" {
+		return fmt.Errorf("repo must be set")
+	}
+	for _, field := range []struct {
+		name, value string
+		allowed     bool
+	}{
+		{"branch", spec.Branch, sourceType == addonsv1alpha1.SourceTypeGit},
+		{"chart", spec.Chart, sourceType == addonsv1alpha1.SourceTypeHelm},
+		{"dir", spec.Dir, sourceType != addonsv1alpha1.SourceTypeHelm},
+		{"sourceFormat", spec.SourceFormat, sourceType != addonsv1alpha1.SourceTypeHelm},
+	} {
+		if field.value != "" && !field.allowed {
+			return fmt.Errorf("%s is not supported by sourceType %s", field.name, sourceType)
+		}
+	}
+	if sourceType == addonsv1alpha1.SourceTypeHelm && spec.Chart == "" {
+		return fmt.Errorf("chart must be set for sourceType helm")
+	}
+
+	auth := spec.Auth
+	if auth == "" {
+		auth = "none"
+	}
+	if !contains(authTypes[sourceType], auth) {
+		return fmt.Errorf("auth %s is not supported by sourceType %s, use one of %s", auth, sourceType, strings.Join(authTypes[sourceType], ", "))
+	}
+	needsSecret := auth == "ssh" || auth == "cookiefile" || auth == "token"
+	if needsSecret != (spec.SecretRef != "") {
+		if needsSecret {
+			return fmt.Errorf("secretRef must be set for auth %s", auth)
+		}
+		return fmt.Errorf("secretRef is not used by auth %s", auth)
+	}
+	if (auth == "gcpserviceaccount") != (spec.GCPServiceAccountEmail != "") {
+		return fmt.Errorf("gcpServiceAccountEmail must be set if and only if auth is gcpserviceaccount")
+	}
+	if spec.Period != nil && spec.Period.Duration <= 0 {
+		return fmt.Errorf("period must be positive, got %s", spec.Period.Duration)
+	}
+	return nil
+}
+
+func sourceTypeOf(spec *addonsv1alpha1.SyncSource) addonsv1alpha1.SourceType {
+	if spec.SourceType == "" {
+		return addonsv1alpha1.SourceTypeGit
+	}
+	return spec.SourceType
+}
+
+// ociImage appends the revision to the image, as a digest or as a tag.
+func ociImage(image, revision string) string {
+	switch {
+	case revision == "":
+		return image
+	case strings.Contains(revision, ":"):
+		return image + "@" + revision
+	default:
+		return image + ":" + revision
+	}
+}
+
+func contains(values []string, value string) bool {
+	for _, v := range values {
+		if v == value {
+			return true
+		}
+	}
+	return false
+}
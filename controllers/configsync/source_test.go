@@ -0,0 +1,134 @@
+package configsync
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	addonsv1alpha1 "github.com/yuwenma/moss/moss/api/v1alpha1"
+)
+
+func TestSyncSpec(t *testing.T) {
+	tests := []struct {
+		name string
+		spec addonsv1alpha1.SyncSource
+		want map[string]interface{}
+	}{
+		{
+			name: "git",
+			spec: addonsv1alpha1.SyncSource{
+				Repo:      "git@github.com:example/platform-config",
+				Branch:    "main",
+				Dir:       "clusters/prod",
+				Auth:      "ssh",
+				SecretRef: "git-creds",
+				Period:    &metav1.Duration{Duration: 30 * time.Second},
+			},
+			want: map[string]interface{}{
+				"sourceType": "git",
+				"git": map[string]interface{}{
+					"repo":      "git@github.com:example/platform-config",
+					"branch":    "main",
+					"dir":       "clusters/prod",
+					"auth":      "ssh",
+					"secretRef": map[string]interface{}{"name": "git-creds"},
+					"period":    "30s",
+				},
+			},
+		},
+		{
+			name: "oci digest",
+			spec: addonsv1alpha1.SyncSource{
+				SourceType: addonsv1alpha1.SourceTypeOCI,
+				Repo:       "us-docker.pkg.dev/example/configs/prod",
+				Revision:   "sha256:0123",
+				Auth:       "k8sserviceaccount",
+			},
+			want: map[string]interface{}{
+				"sourceType": "oci",
+				"oci": map[string]interface{}{
+					"image": "us-docker.pkg.dev/example/configs/prod@sha256:0123",
+					"auth":  "k8sserviceaccount",
+				},
+			},
+		},
+		{
+			name: "helm",
+			spec: addonsv1alpha1.SyncSource{
+				SourceType: addonsv1alpha1.SourceTypeHelm,
+				Repo:       "https://charts.example.com",
+				Chart:      "platform",
+				Revision:   "1.2.3",
+			},
+			want: map[string]interface{}{
+				"sourceType": "helm",
+				"helm": map[string]interface{}{
+					"repo":    "https://charts.example.com",
+					"chart":   "platform",
+					"version": "1.2.3",
+				},
+			},
+		},
+	}
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			got, err := syncSpec(&tc.spec)
+			if err != nil {
+				t.Fatalf("syncSpec() error = %v", err)
+			}
+			if !reflect.DeepEqual(got, tc.want) {
+				t.Errorf("syncSpec() = %v, want %v", got, tc.want)
+			}
+		})
+	}
+}
+
+func TestValidateSyncSource(t *testing.T) {
+	tests := []struct {
+		name string
+		spec addonsv1alpha1.SyncSource
+		want string
+	}{
+		{
+			name: "missing repo",
+			spec: addonsv1alpha1.SyncSource{Branch: "main"},
+			want: "repo must be set",
+		},
+		{
+			name: "branch of oci",
+			spec: addonsv1alpha1.SyncSource{SourceType: addonsv1alpha1.SourceTypeOCI, Repo: "example/configs", Branch: "main"},
+			want: "branch is not supported by sourceType oci",
+		},
+		{
+			name: "helm without chart",
+			spec: addonsv1alpha1.SyncSource{SourceType: addonsv1alpha1.SourceTypeHelm, Repo: "https://charts.example.com"},
+			want: "chart must be set for sourceType helm",
+		},
+		{
+			name: "unsupported auth",
+			spec: addonsv1alpha1.SyncSource{SourceType: addonsv1alpha1.SourceTypeOCI, Repo: "example/configs", Auth: "ssh"},
+			want: "auth ssh is not supported by sourceType oci",
+		},
+		{
+			name: "missing secret",
+			spec: addonsv1alpha1.SyncSource{Repo: "https://github.com/example/configs", Auth: "token"},
+			want: "secretRef must be set for auth token",
+		},
+		{
+			name: "missing service account",
+			spec: addonsv1alpha1.SyncSource{Repo: "https://github.com/example/configs", Auth: "gcpserviceaccount"},
+			want: "gcpServiceAccountEmail must be set if and only if auth is gcpserviceaccount",
+		},
+	}
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			err := validateSyncSource(&tc.spec)
+			if err == nil || !strings.Contains(err.Error(), tc.want) {
+				t.Errorf("validateSyncSource() error = %v, want error containing %q", err, tc.want)
+			}
+		})
+	}
+}
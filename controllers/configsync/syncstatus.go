@@ -0,0 +1,235 @@
+package configsync
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/handler"
+	"sigs.k8s.io/controller-runtime/pkg/source"
+	"sigs.k8s.io/kubebuilder-declarative-pattern/pkg/patterns/declarative"
+
+	addonsv1alpha1 "github.com/yuwenma/moss/moss/api/v1alpha1"
+	mossstatus "github.com/yuwenma/moss/moss/pkg/status"
+)
+
+// syncPhases are the phases of a RootSync or RepoSync reporting errors in their status, in order.
+var syncPhases = []string{"source", "rendering", "sync"}
+
+// syncKinds are the kinds syncing a source of truth, and the CRDs the operator installs them with.
+var syncKinds = []struct {
+	gvk schema.GroupVersionKind
+	crd string
+}{
+	{gvk: rootSyncGVK, crd: rootSyncCRD},
+	{gvk: repoSyncGVK, crd: repoSyncCRD},
+}
+
+const (
+	conditionReady   = "Ready"
+	conditionSyncing = "Syncing"
+	conditionStalled = "Stalled"
+)
+
+// syncStatusOf summarizes the status of a live RootSync or RepoSync.
+func syncStatusOf(u *unstructured.Unstructured) addonsv1alpha1.SyncStatus {
+	var s addonsv1alpha1.SyncStatus
+	s.Commit, _, _ = unstructured.NestedString(u.Object, "status", "sync", "commit")
+	if s.Commit == "" {
+		s.Commit, _, _ = unstructured.NestedString(u.Object, "status", "source", "commit")
+	}
+	for _, phase := range syncPhases {
+		errs, _, _ := unstructured.NestedSlice(u.Object, "status", phase, "errors")
+		for _, e := range errs {
+			m, ok := e.(map[string]interface{})
+			if !ok {
+				continue
+			}
+			code, _, _ := unstructured.NestedString(m, "code")
+			message, _, _ := unstructured.NestedString(m, "errorMessage")
+			s.Errors = append(s.Errors, addonsv1alpha1.SyncError{Phase: phase, Code: code, Message: message})
+		}
+	}
+	return s
+}
+
+// buildStatus aggregates the status of the RootSyncs and RepoSyncs of the cluster into the status of the
+// ConfigSync. The RootSyncs and RepoSyncs are watched once their CRD is established.
+func (r *ConfigSyncReconciler) buildStatus(ctx context.Context, info *declarative.StatusInfo) error {
+	configSync, ok := info.Subject.(*addonsv1alpha1.ConfigSync)
+	if !ok {
+		return fmt.Errorf("expected ConfigSync object, got %T", info.Subject)
+	}
+
+	var syncs []addonsv1alpha1.SyncSummary
+	for _, kind := range syncKinds {
+		established, _, err := mossstatus.IsCurrent(ctx, r.apiReader, mossstatus.CustomResourceDefinitionGVK, types.NamespacedName{Name: kind.crd})
+		if err != nil {
+			return err
+		}
+		if !established {
+			continue
+		}
+		if err := r.watchSyncs(kind.gvk); err != nil {
+			return err
+		}
+		list := &unstructured.UnstructuredList{}
+		list.SetGroupVersionKind(kind.gvk.GroupVersion().WithKind(kind.gvk.Kind + "List"))
+		if err := r.apiReader.List(ctx, list); err != nil {
+			return fmt.Errorf("error listing %s objects: %w", kind.gvk.Kind, err)
+		}
+		for i := range list.Items {
+			syncs = append(syncs, syncSummaryOf(&list.Items[i]))
+		}
+	}
+
+	blockedBy, err := mossstatus.BlockingObject(ctx, info)
+	if err != nil {
+		return err
+	}
+	configSync.Status.BlockedBy = blockedBy
+	if deployed := mossstatus.DeployedPackage(info, r.loader); deployed != nil {
+		configSync.Status.DeployedPackage = *deployed
+	}
+	configSync.Status.AvailableVersion = mossstatus.AvailableVersion(info, r.loader)
+	mossstatus.Rollback(info, r.loader, configSync.Status.Healthy, &configSync.Status.RollbackStatus, &configSync.Status.Conditions)
+	if inventory := mossstatus.Inventory(info); inventory != nil {
+		configSync.Status.Inventory = inventory
+	}
+	configSync.Status.Syncs = syncs
+	configSync.Status.ErrorCount = 0
+	for _, s := range syncs {
+		configSync.Status.ErrorCount += s.SourceErrorCount + s.RenderingErrorCount + s.SyncErrorCount
+	}
+	setSyncConditions(&configSync.Status, configSync.Generation)
+	return nil
+}
+
+// watchSyncs watches the objects of gvk, which must be installed, the first time it is called for gvk.
+func (r *ConfigSyncReconciler) watchSyncs(gvk schema.GroupVersionKind) error {
+	r.watchesMutex.Lock()
+	defer r.watchesMutex.Unlock()
+
+	if r.syncWatches[gvk] {
+		return nil
+	}
+	u := &unstructured.Unstructured{}
+	u.SetGroupVersionKind(gvk)
+	if err := r.controller.Watch(&source.Kind{Type: u}, handler.EnqueueRequestsFromMapFunc(r.allConfigSyncs)); err != nil {
+		return fmt.Errorf("error watching %s objects: %w", gvk.Kind, err)
+	}
+	r.syncWatches[gvk] = true
+	return nil
+}
+
+// syncSummaryOf summarizes the status of a live RootSync or RepoSync.
+func syncSummaryOf(u *unstructured.Unstructured) addonsv1alpha1.SyncSummary {
+	s := addonsv1alpha1.SyncSummary{
+		Kind:      u.GetKind(),
+		Namespace: u.GetNamespace(),
+		Name:      u.GetName(),
+		Syncing:   conditionTrue(u, conditionSyncing),
+		Stalled:   conditionTrue(u, conditionStalled),
+	}
+	s.SourceCommit, _, _ = unstructured.NestedString(u.Object, "status", "source", "commit")
+	s.RenderingCommit, _, _ = unstructured.NestedString(u.Object, "status", "rendering", "commit")
+	s.SyncCommit, _, _ = unstructured.NestedString(u.Object, "status", "sync", "commit")
+	s.SourceErrorCount = errorCount(u, "source")
+	s.RenderingErrorCount = errorCount(u, "rendering")
+	s.SyncErrorCount = errorCount(u, "sync")
+	return s
+}
+
+// errorCount returns the number of errors reported in phase. The errors listed in the status are
+// truncated, the summary has their total count.
+func errorCount(u *unstructured.Unstructured, phase string) int {
+	total, _, _ := unstructured.NestedFieldNoCopy(u.Object, "status", phase, "errorSummary", "totalCount")
+	switch total := total.(type) {
+	case int64:
+		return int(total)
+	case float64:
+		return int(total)
+	}
+	errs, _, _ := unstructured.NestedSlice(u.Object, "status", phase, "errors")
+	return len(errs)
+}
+
+func conditionTrue(u *unstructured.Unstructured, conditionType string) bool {
+	conditions, _, _ := unstructured.NestedSlice(u.Object, "status", "conditions")
+	for _, c := range conditions {
+		m, ok := c.(map[string]interface{})
+		if !ok {
+			continue
+		}
+		if m["type"] == conditionType {
+			return m["status"] == string(metav1.ConditionTrue)
+		}
+	}
+	return false
+}
+
+// setSyncConditions sets the Ready, Syncing and Stalled conditions from the syncs and the health of the
+// installation. Ready replaces the condition set by the kstatus check, telling which object blocks it.
+func setSyncConditions(status *addonsv1alpha1.ConfigSyncStatus, generation int64) {
+	var syncing, stalled []string
+	for _, s := range status.Syncs {
+		name := s.Kind + " " + objectName(s.Namespace, s.Name)
+		if s.Syncing {
+			syncing = append(syncing, name)
+		}
+		if s.Stalled || s.SourceErrorCount+s.RenderingErrorCount+s.SyncErrorCount != 0 {
+			stalled = append(stalled, name)
+		}
+	}
+
+	syncingCondition := metav1.Condition{Type: conditionSyncing, Status: metav1.ConditionFalse, Reason: "Synced"}
+	if len(syncing) != 0 {
+		syncingCondition.Status = metav1.ConditionTrue
+		syncingCondition.Reason = "Syncing"
+		syncingCondition.Message = "syncing: " + strings.Join(syncing, ", ")
+	}
+	stalledCondition := metav1.Condition{Type: conditionStalled, Status: metav1.ConditionFalse, Reason: "NoErrors"}
+	if len(stalled) != 0 {
+		stalledCondition.Status = metav1.ConditionTrue
+		stalledCondition.Reason = "SyncErrors"
+		stalledCondition.Message = fmt.Sprintf("%d errors, stalled: %s", status.ErrorCount, strings.Join(stalled, ", "))
+	}
+	readyCondition := metav1.Condition{Type: conditionReady, Status: metav1.ConditionTrue, Reason: "Synced"}
+	switch {
+	case !status.Healthy:
+		readyCondition.Status = metav1.ConditionFalse
+		readyCondition.Reason = "Installing"
+		readyCondition.Message = fmt.Sprintf("Config Sync is %s", status.Phase)
+		if b := status.BlockedBy; b != nil {
+			readyCondition.Message = fmt.Sprintf("%s %s is %s", b.Kind, objectName(b.Namespace, b.Name), b.Status)
+			if b.Message != "" {
+				readyCondition.Message += ": " + b.Message
+			}
+		}
+	case len(stalled) != 0:
+		readyCondition.Status = metav1.ConditionFalse
+		readyCondition.Reason = stalledCondition.Reason
+		readyCondition.Message = stalledCondition.Message
+	case len(syncing) != 0:
+		readyCondition.Status = metav1.ConditionFalse
+		readyCondition.Reason = syncingCondition.Reason
+		readyCondition.Message = syncingCondition.Message
+	}
+
+	for _, condition := range []metav1.Condition{readyCondition, syncingCondition, stalledCondition} {
+		condition.ObservedGeneration = generation
+		meta.SetStatusCondition(&status.Conditions, condition)
+	}
+}
+
+func objectName(namespace, name string) string {
+	if namespace == "" {
+		return name
+	}
+	return namespace + "/" + name
+}
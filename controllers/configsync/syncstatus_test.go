@@ -0,0 +1,208 @@
+package configsync
+
+import (
+	"reflect"
+	"testing"
+
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"sigs.k8s.io/yaml"
+
+	addonsv1alpha1 "github.com/yuwenma/moss/moss/api/v1alpha1"
+)
+
+func TestSyncStatusOf(t *testing.T) {
+	tests := []struct {
+		name   string
+		status string
+		want   addonsv1alpha1.SyncStatus
+	}{
+		{
+			name: "synced",
+			status: `
+source:
+  commit: abc
+sync:
+  commit: abc
+`,
+			want: addonsv1alpha1.SyncStatus{Commit: "abc"},
+		},
+		{
+			name: "source errors",
+			status: `
+source:
+  commit: def
+  errors:
+  - code: "1017"
+    errorMessage: repo not found
+rendering:
+  errors:
+  - code: "1068"
+    errorMessage: kustomize failed
+sync:
+  commit: abc
+`,
+			want: addonsv1alpha1.SyncStatus{
+				Commit: "abc",
+				Errors: []addonsv1alpha1.SyncError{
+					{Phase: "source", Code: "1017", Message: "repo not found"},
+					{Phase: "rendering", Code: "1068", Message: "kustomize failed"},
+				},
+			},
+		},
+		{
+			name: "not synced yet",
+			status: `
+source:
+  commit: abc
+`,
+			want: addonsv1alpha1.SyncStatus{Commit: "abc"},
+		},
+	}
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			status := map[string]interface{}{}
+			if err := yaml.Unmarshal([]byte(tc.status), &status); err != nil {
+				t.Fatal(err)
+			}
+			u := &unstructured.Unstructured{Object: map[string]interface{}{"status": status}}
+			if got := syncStatusOf(u); !reflect.DeepEqual(got, tc.want) {
+				t.Errorf("syncStatusOf() = %+v, want %+v", got, tc.want)
+			}
+		})
+	}
+}
+
+func TestSyncSummaryOf(t *testing.T) {
+	u := &unstructured.Unstructured{}
+	if err := yaml.Unmarshal([]byte(`
+apiVersion: configsync.gke.io/v1beta1
+kind: RepoSync
+metadata:
+  namespace: gamestore
+  name: repo-sync
+status:
+  conditions:
+  - type: Reconciling
+    status: "False"
+  - type: Syncing
+    status: "True"
+  source:
+    commit: def
+    errors:
+    - code: "1017"
+      errorMessage: repo not found
+    errorSummary:
+      totalCount: 3
+      truncated: true
+  rendering:
+    commit: abc
+  sync:
+    commit: abc
+    errors:
+    - code: "2009"
+      errorMessage: apply failed
+`), &u.Object); err != nil {
+		t.Fatal(err)
+	}
+	want := addonsv1alpha1.SyncSummary{
+		Kind:             "RepoSync",
+		Namespace:        "gamestore",
+		Name:             "repo-sync",
+		SourceCommit:     "def",
+		RenderingCommit:  "abc",
+		SyncCommit:       "abc",
+		Syncing:          true,
+		SourceErrorCount: 3,
+		SyncErrorCount:   1,
+	}
+	if got := syncSummaryOf(u); !reflect.DeepEqual(got, want) {
+		t.Errorf("syncSummaryOf() = %+v, want %+v", got, want)
+	}
+}
+
+func TestSetSyncConditions(t *testing.T) {
+	rootSync := addonsv1alpha1.SyncSummary{Kind: "RootSync", Namespace: "config-management-system", Name: "root-sync"}
+	tests := []struct {
+		name    string
+		healthy bool
+		syncs   func() []addonsv1alpha1.SyncSummary
+		want    map[string]metav1.ConditionStatus
+		reason  string
+	}{
+		{
+			name:    "synced",
+			healthy: true,
+			syncs:   func() []addonsv1alpha1.SyncSummary { return []addonsv1alpha1.SyncSummary{rootSync} },
+			want:    map[string]metav1.ConditionStatus{"Ready": metav1.ConditionTrue, "Syncing": metav1.ConditionFalse, "Stalled": metav1.ConditionFalse},
+			reason:  "Synced",
+		},
+		{
+			name:    "not installed",
+			healthy: false,
+			syncs:   func() []addonsv1alpha1.SyncSummary { return nil },
+			want:    map[string]metav1.ConditionStatus{"Ready": metav1.ConditionFalse, "Syncing": metav1.ConditionFalse, "Stalled": metav1.ConditionFalse},
+			reason:  "Installing",
+		},
+		{
+			name:    "syncing",
+			healthy: true,
+			syncs: func() []addonsv1alpha1.SyncSummary {
+				s := rootSync
+				s.Syncing = true
+				return []addonsv1alpha1.SyncSummary{s}
+			},
+			want:   map[string]metav1.ConditionStatus{"Ready": metav1.ConditionFalse, "Syncing": metav1.ConditionTrue, "Stalled": metav1.ConditionFalse},
+			reason: "Syncing",
+		},
+		{
+			name:    "errors",
+			healthy: true,
+			syncs: func() []addonsv1alpha1.SyncSummary {
+				s := rootSync
+				s.Syncing = true
+				s.RenderingErrorCount = 1
+				return []addonsv1alpha1.SyncSummary{s}
+			},
+			want:   map[string]metav1.ConditionStatus{"Ready": metav1.ConditionFalse, "Syncing": metav1.ConditionTrue, "Stalled": metav1.ConditionTrue},
+			reason: "SyncErrors",
+		},
+	}
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			status := &addonsv1alpha1.ConfigSyncStatus{Syncs: tc.syncs()}
+			status.Healthy = tc.healthy
+			setSyncConditions(status, 1)
+			for conditionType, want := range tc.want {
+				c := meta.FindStatusCondition(status.Conditions, conditionType)
+				if c == nil || c.Status != want {
+					t.Errorf("condition %s = %+v, want status %s", conditionType, c, want)
+				}
+			}
+			if c := meta.FindStatusCondition(status.Conditions, "Ready"); c != nil && c.Reason != tc.reason {
+				t.Errorf("Ready reason = %s, want %s", c.Reason, tc.reason)
+			}
+		})
+	}
+}
+
+func TestSetSyncConditionsBlocked(t *testing.T) {
+	status := &addonsv1alpha1.ConfigSyncStatus{
+		BlockedBy: &addonsv1alpha1.ObjectStatus{
+			Group:     "apps",
+			Kind:      "Deployment",
+			Namespace: "config-management-system",
+			Name:      "config-management-operator",
+			Status:    "InProgress",
+			Message:   "Available: 0/1",
+		},
+	}
+	status.Phase = "InProgress"
+	setSyncConditions(status, 1)
+
+	want := "Deployment config-management-system/config-management-operator is InProgress: Available: 0/1"
+	if c := meta.FindStatusCondition(status.Conditions, "Ready"); c == nil || c.Message != want {
+		t.Errorf("Ready condition = %+v, want message %q", c, want)
+	}
+}
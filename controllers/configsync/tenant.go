@@ -0,0 +1,309 @@
+package configsync
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"strings"
+
+	"github.com/go-logr/logr"
+	rbacv1 "k8s.io/api/rbac/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/types"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/controller"
+	"sigs.k8s.io/controller-runtime/pkg/handler"
+	"sigs.k8s.io/controller-runtime/pkg/log"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+	"sigs.k8s.io/controller-runtime/pkg/source"
+	"sigs.k8s.io/kubebuilder-declarative-pattern/pkg/patterns/addon/pkg/status"
+	"sigs.k8s.io/kubebuilder-declarative-pattern/pkg/patterns/declarative"
+	"sigs.k8s.io/kubebuilder-declarative-pattern/pkg/patterns/declarative/pkg/applier"
+	"sigs.k8s.io/kubebuilder-declarative-pattern/pkg/patterns/declarative/pkg/manifest"
+
+	addonsv1alpha1 "github.com/yuwenma/moss/moss/api/v1alpha1"
+	mossstatus "github.com/yuwenma/moss/moss/pkg/status"
+	"github.com/yuwenma/moss/moss/pkg/transforms"
+)
+
+const (
+	// defaultRepoSyncName is the name of the RepoSync Config Sync names the reconciler of after its
+	// namespace only.
+	defaultRepoSyncName = "repo-sync"
+	// repoSyncCRD is installed by the operator once multi-repo mode is enabled.
+	repoSyncCRD = "reposyncs.configsync.gke.io"
+)
+
+// DefaultTenantClusterRoles are the ClusterRoles the tenants can bind to their reconciler by default, the
+// user-facing roles of Kubernetes.
+var DefaultTenantClusterRoles = []string{"admin", "edit", "view"}
+
+var repoSyncGVK = schema.GroupVersionKind{Group: "configsync.gke.io", Version: "v1beta1", Kind: "RepoSync"}
+
+var _ reconcile.Reconciler = &ConfigSyncTenantReconciler{}
+
+// ConfigSyncTenantReconciler reconciles a ConfigSyncTenant object
+type ConfigSyncTenantReconciler struct {
+	client.Client
+	Log    logr.Logger
+	Scheme *runtime.Scheme
+
+	// ClusterRoles are the ClusterRoles the tenants may bind to their reconciler, DefaultTenantClusterRoles
+	// if empty. The manager must be allowed to bind them. Any other role is rejected, so that the users
+	// creating tenants cannot get the manager to bind roles they hold no permission to bind themselves.
+	ClusterRoles []string
+
+	// apiReader reads the objects the RepoSync depends on, bypassing the cache.
+	apiReader client.Reader
+
+	declarative.Reconciler
+}
+
+//+kubebuilder:rbac:groups=configdelivery.anthos.io,resources=configsynctenants,verbs=get;list;watch;create;update;patch;delete
+//+kubebuilder:rbac:groups=configdelivery.anthos.io,resources=configsynctenants/status,verbs=get;update;patch
+
+// SetupWithManager sets up the controller with the Manager.
+func (r *ConfigSyncTenantReconciler) SetupWithManager(mgr ctrl.Manager) error {
+	r.apiReader = mgr.GetAPIReader()
+
+	labels := map[string]string{
+		"k8s-app": "configsynctenant",
+	}
+
+	applier := applier.NewApplySetApplier(metav1.PatchOptions{}, metav1.DeleteOptions{}, applier.ApplysetOptions{})
+	watchLabels := declarative.SourceLabel(mgr.GetScheme())
+	if err := r.Reconciler.Init(mgr, &addonsv1alpha1.ConfigSyncTenant{},
+		declarative.WithManifestController(tenantManifest{}),
+		// Transforms adding objects run before the labels are added.
+		declarative.WithObjectTransform(r.addRepoSync),
+		declarative.WithObjectTransform(declarative.AddLabels(labels)),
+		declarative.WithOwner(declarative.SourceAsOwner),
+		declarative.WithLabels(watchLabels),
+		declarative.WithStatus(mossstatus.WithAddonStatus(mgr.GetClient(),
+			mossstatus.WithReconcileErrors(status.NewKstatusCheck(mgr.GetClient(), &r.Reconciler)),
+			buildTenantStatus,
+		)),
+		declarative.WithApplier(applier),
+		declarative.WithApplyPrune(),
+	); err != nil {
+		return err
+	}
+
+	c, err := controller.New("configsynctenant-controller", mgr, controller.Options{Reconciler: r})
+	if err != nil {
+		return err
+	}
+
+	// Watch for changes to ConfigSyncTenant
+	err = c.Watch(&source.Kind{Type: &addonsv1alpha1.ConfigSyncTenant{}}, &handler.EnqueueRequestForObject{})
+	if err != nil {
+		return err
+	}
+
+	// Watch for changes to deployed objects
+	childOptions := declarative.WatchChildrenOptions{
+		Manager:    mgr,
+		RESTConfig: mgr.GetConfig(),
+		LabelMaker: watchLabels,
+		Controller: c,
+		Reconciler: r,
+	}
+	_, err = declarative.WatchChildren(childOptions)
+	if err != nil {
+		return err
+	}
+
+	// Watch for the RepoSync CRD installed by the operator
+	crd := &metav1.PartialObjectMetadata{}
+	crd.SetGroupVersionKind(mossstatus.CustomResourceDefinitionGVK)
+	err = c.Watch(&source.Kind{Type: crd}, handler.EnqueueRequestsFromMapFunc(r.tenantsForCRD))
+	if err != nil {
+		return err
+	}
+
+	return nil
+}
+
+// tenantManifest is the manifest controller of ConfigSyncTenant. There is no package to load, the objects
+// are all generated by the transforms.
+type tenantManifest struct{}
+
+func (tenantManifest) ResolveManifest(ctx context.Context, object runtime.Object) (map[string]string, error) {
+	// A single empty manifest, so that the transforms run once.
+	return map[string]string{"configsynctenant": ""}, nil
+}
+
+// addRepoSync adds the RepoSync of the tenant, and the RoleBinding granting its reconciler access to the
+// namespace. The RepoSync is added once the referenced ConfigSync installed its CRD.
+func (r *ConfigSyncTenantReconciler) addRepoSync(ctx context.Context, o declarative.DeclarativeObject, objects *manifest.Objects) error {
+	tenant, ok := o.(*addonsv1alpha1.ConfigSyncTenant)
+	if !ok {
+		return fmt.Errorf("expected ConfigSyncTenant object, got %T", o)
+	}
+	repoSync, err := repoSyncObject(tenant)
+	if err != nil {
+		return fmt.Errorf("spec.repoSync: %w", err)
+	}
+	if err := r.checkClusterRole(tenant); err != nil {
+		return err
+	}
+	if err := transforms.AddObject(objects, reconcilerRoleBinding(tenant)); err != nil {
+		return err
+	}
+
+	ready, reason, err := r.repoSyncReady(ctx, tenant)
+	if err != nil {
+		return err
+	}
+	if !ready {
+		log.FromContext(ctx).Info("waiting to apply the RepoSync", "reason", reason)
+	}
+	return transforms.AddGated(ctx, r.apiReader, objects, ready, []*unstructured.Unstructured{repoSync})
+}
+
+// repoSyncReady reports whether the referenced ConfigSync exists and the RepoSync CRD is established.
+func (r *ConfigSyncTenantReconciler) repoSyncReady(ctx context.Context, tenant *addonsv1alpha1.ConfigSyncTenant) (bool, string, error) {
+	configSync := &addonsv1alpha1.ConfigSync{}
+	if err := r.apiReader.Get(ctx, types.NamespacedName{Name: tenant.Spec.ConfigSyncRef}, configSync); err != nil {
+		return false, "", fmt.Errorf("error reading ConfigSync %s: %w", tenant.Spec.ConfigSyncRef, err)
+	}
+	return mossstatus.IsCurrent(ctx, r.apiReader, mossstatus.CustomResourceDefinitionGVK, types.NamespacedName{Name: repoSyncCRD})
+}
+
+func repoSyncObject(tenant *addonsv1alpha1.ConfigSyncTenant) (*unstructured.Unstructured, error) {
+	if format := tenant.Spec.RepoSync.SourceFormat; format != "" && format != "unstructured" {
+		return nil, fmt.Errorf("sourceFormat %s is not supported by RepoSyncs, use unstructured", format)
+	}
+	repoSyncSpec, err := syncSpec(&tenant.Spec.RepoSync)
+	if err != nil {
+		return nil, err
+	}
+	u := &unstructured.Unstructured{Object: map[string]interface{}{"spec": repoSyncSpec}}
+	u.SetGroupVersionKind(repoSyncGVK)
+	u.SetNamespace(tenant.Namespace)
+	u.SetName(tenant.Name)
+	return u, nil
+}
+
+// checkClusterRole rejects the ClusterRoles of tenants which are not in the ClusterRoles of the reconciler.
+func (r *ConfigSyncTenantReconciler) checkClusterRole(tenant *addonsv1alpha1.ConfigSyncTenant) error {
+	allowed := r.ClusterRoles
+	if len(allowed) == 0 {
+		allowed = DefaultTenantClusterRoles
+	}
+	clusterRole := tenantClusterRole(tenant)
+	for _, name := range allowed {
+		if name == clusterRole {
+			return nil
+		}
+	}
+	return fmt.Errorf("spec.clusterRole %q is not allowed, tenants can bind %s", clusterRole, strings.Join(allowed, ", "))
+}
+
+// tenantClusterRole returns spec.clusterRole, admin if unset.
+func tenantClusterRole(tenant *addonsv1alpha1.ConfigSyncTenant) string {
+	if tenant.Spec.ClusterRole == "" {
+		return "admin"
+	}
+	return tenant.Spec.ClusterRole
+}
+
+// reconcilerRoleBinding binds the ClusterRole of the tenant to the service account Config Sync runs the
+// reconciler of the RepoSync as. The RepoSync is named after the tenant, so that the tenants of a
+// namespace have their own RepoSync and reconciler. As the roleRef of a RoleBinding cannot be changed,
+// the RoleBinding is named after the ClusterRole too: changing spec.clusterRole creates a new binding
+// and prunes the previous one.
+func reconcilerRoleBinding(tenant *addonsv1alpha1.ConfigSyncTenant) *rbacv1.RoleBinding {
+	clusterRole := tenantClusterRole(tenant)
+	return &rbacv1.RoleBinding{
+		TypeMeta: metav1.TypeMeta{APIVersion: "rbac.authorization.k8s.io/v1", Kind: "RoleBinding"},
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      roleBindingName(tenant.Name, clusterRole),
+			Namespace: tenant.Namespace,
+		},
+		RoleRef: rbacv1.RoleRef{
+			APIGroup: rbacv1.GroupName,
+			Kind:     "ClusterRole",
+			Name:     clusterRole,
+		},
+		Subjects: []rbacv1.Subject{{
+			Kind:      rbacv1.ServiceAccountKind,
+			Namespace: configSyncNamespace,
+			Name:      reconcilerName(tenant.Namespace, tenant.Name),
+		}},
+	}
+}
+
+// roleBindingName returns the name of the RoleBinding of the ClusterRole of the tenant name. The
+// ClusterRole is hashed to a fixed length suffix, so that the names of different tenants and roles,
+// e.g. tenant a-edit binding view and tenant a binding edit-view, cannot collide.
+func roleBindingName(name, clusterRole string) string {
+	h := sha256.Sum256([]byte(clusterRole))
+	return "configsync-" + name + "-" + hex.EncodeToString(h[:])[:8]
+}
+
+// reconcilerName returns the name Config Sync gives to the reconciler of the RepoSync name in namespace,
+// and to its service account.
+func reconcilerName(namespace, name string) string {
+	if name == defaultRepoSyncName {
+		return "ns-reconciler-" + namespace
+	}
+	return fmt.Sprintf("ns-reconciler-%s-%s-%d", namespace, name, len(name))
+}
+
+// buildTenantStatus reports the commit and errors of the RepoSync in the status of the tenant.
+func buildTenantStatus(ctx context.Context, info *declarative.StatusInfo) error {
+	tenant, ok := info.Subject.(*addonsv1alpha1.ConfigSyncTenant)
+	if !ok {
+		return fmt.Errorf("expected ConfigSyncTenant object, got %T", info.Subject)
+	}
+	if info.LiveObjects == nil {
+		return nil
+	}
+	repoSync, err := info.LiveObjects(ctx, repoSyncGVK, types.NamespacedName{Namespace: tenant.Namespace, Name: tenant.Name})
+	if apierrors.IsNotFound(err) || meta.IsNoMatchError(err) {
+		// Not applied yet, e.g. while waiting for the RepoSync CRD.
+		tenant.Status.RepoSync = addonsv1alpha1.SyncStatus{}
+		tenant.Status.Healthy = false
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("error reading RepoSync %s/%s: %w", tenant.Namespace, tenant.Name, err)
+	}
+	tenant.Status.RepoSync = syncStatusOf(repoSync)
+	if len(tenant.Status.RepoSync.Errors) != 0 {
+		tenant.Status.Healthy = false
+	}
+	return nil
+}
+
+// tenantsForCRD maps the RepoSync CRD to the ConfigSyncTenant objects waiting for it to be established.
+func (r *ConfigSyncTenantReconciler) tenantsForCRD(o client.Object) []reconcile.Request {
+	if o.GetName() != repoSyncCRD {
+		return nil
+	}
+	ctx := context.Background()
+	list := &addonsv1alpha1.ConfigSyncTenantList{}
+	if err := r.Client.List(ctx, list); err != nil {
+		log.FromContext(ctx).Error(err, "listing ConfigSyncTenant objects")
+		return nil
+	}
+	var requests []reconcile.Request
+	for _, tenant := range list.Items {
+		requests = append(requests, reconcile.Request{NamespacedName: types.NamespacedName{Namespace: tenant.Namespace, Name: tenant.Name}})
+	}
+	return requests
+}
+
+// +kubebuilder:rbac:groups=configsync.gke.io,resources=reposyncs,verbs=get;list;watch;create;update;delete;patch
+// +kubebuilder:rbac:groups=rbac.authorization.k8s.io,resources=rolebindings,verbs=get;list;watch;create;update;delete;patch
+// The manager can only bind the ClusterRoles of the tenants if it holds or may bind them. It may bind the
+// DefaultTenantClusterRoles, the roles added with --tenant-cluster-roles must be granted separately.
+// +kubebuilder:rbac:groups=rbac.authorization.k8s.io,resources=clusterroles,verbs=bind,resourceNames=admin;edit;view
@@ -0,0 +1,131 @@
+package configsync
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"testing"
+
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/meta"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/kubebuilder-declarative-pattern/pkg/patterns/declarative"
+
+	addonsv1alpha1 "github.com/yuwenma/moss/moss/api/v1alpha1"
+)
+
+func TestCheckClusterRole(t *testing.T) {
+	tests := []struct {
+		name         string
+		clusterRoles []string
+		clusterRole  string
+		want         string
+	}{
+		{name: "default role", clusterRole: ""},
+		{name: "default allowed role", clusterRole: "edit"},
+		{name: "escalation", clusterRole: "cluster-admin", want: `spec.clusterRole "cluster-admin" is not allowed, tenants can bind admin, edit, view`},
+		{name: "configured role", clusterRoles: []string{"view", "tenant-operator"}, clusterRole: "tenant-operator"},
+		{name: "not configured role", clusterRoles: []string{"view"}, clusterRole: "admin", want: `spec.clusterRole "admin" is not allowed`},
+	}
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			r := &ConfigSyncTenantReconciler{ClusterRoles: tc.clusterRoles}
+			tenant := &addonsv1alpha1.ConfigSyncTenant{Spec: addonsv1alpha1.ConfigSyncTenantSpec{ClusterRole: tc.clusterRole}}
+			err := r.checkClusterRole(tenant)
+			if tc.want == "" {
+				if err != nil {
+					t.Errorf("checkClusterRole() error = %v, want nil", err)
+				}
+				return
+			}
+			if err == nil || !strings.Contains(err.Error(), tc.want) {
+				t.Errorf("checkClusterRole() error = %v, want error containing %q", err, tc.want)
+			}
+		})
+	}
+}
+
+func TestReconcilerRoleBinding(t *testing.T) {
+	tests := []struct {
+		name           string
+		tenant         string
+		wantReconciler string
+	}{
+		{name: "default RepoSync", tenant: "repo-sync", wantReconciler: "ns-reconciler-team-a"},
+		{name: "named RepoSync", tenant: "frontend", wantReconciler: "ns-reconciler-team-a-frontend-8"},
+	}
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			tenant := &addonsv1alpha1.ConfigSyncTenant{}
+			tenant.Namespace, tenant.Name = "team-a", tc.tenant
+			tenant.Spec.RepoSync.Repo = "https://github.com/team-a/configs"
+			roleBinding := reconcilerRoleBinding(tenant)
+			if got := roleBinding.Subjects[0].Name; got != tc.wantReconciler {
+				t.Errorf("reconcilerRoleBinding() subject = %s, want %s", got, tc.wantReconciler)
+			}
+			repoSync, err := repoSyncObject(tenant)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if repoSync.GetName() != tc.tenant {
+				t.Errorf("repoSyncObject() name = %s, want %s", repoSync.GetName(), tc.tenant)
+			}
+		})
+	}
+}
+
+func TestRoleBindingName(t *testing.T) {
+	admin := roleBindingName("frontend", "admin")
+	if !strings.HasPrefix(admin, "configsync-frontend-") {
+		t.Errorf("roleBindingName() = %s, want the tenant name in it", admin)
+	}
+	// The roleRef of a RoleBinding is immutable, another role is bound by another RoleBinding.
+	if edit := roleBindingName("frontend", "edit"); edit == admin {
+		t.Errorf("roleBindingName() = %s for both admin and edit", edit)
+	}
+	if a, b := roleBindingName("a-edit", "view"), roleBindingName("a", "edit-view"); a == b {
+		t.Errorf("roleBindingName() = %s for different tenants", a)
+	}
+}
+
+func TestBuildTenantStatus(t *testing.T) {
+	notFound := apierrors.NewNotFound(schema.GroupResource{Group: "configsync.gke.io", Resource: "reposyncs"}, "team-a")
+	tests := []struct {
+		name        string
+		err         error
+		wantErr     bool
+		wantHealthy bool
+	}{
+		{name: "not applied yet", err: fmt.Errorf("error getting object: %w", notFound)},
+		{name: "CRD not installed", err: &meta.NoKindMatchError{GroupKind: repoSyncGVK.GroupKind()}},
+		{name: "read failed", err: apierrors.NewForbidden(schema.GroupResource{Group: "configsync.gke.io", Resource: "reposyncs"}, "team-a", nil), wantErr: true, wantHealthy: true},
+	}
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			tenant := &addonsv1alpha1.ConfigSyncTenant{}
+			tenant.Namespace = "team-a"
+			tenant.Name = "team-a"
+			tenant.Status.Healthy = true
+			tenant.Status.RepoSync.Commit = "0123abc"
+			info := &declarative.StatusInfo{
+				Subject: tenant,
+				LiveObjects: func(context.Context, schema.GroupVersionKind, types.NamespacedName) (*unstructured.Unstructured, error) {
+					return nil, tc.err
+				},
+			}
+			err := buildTenantStatus(context.Background(), info)
+			if (err != nil) != tc.wantErr {
+				t.Fatalf("buildTenantStatus() error = %v, want error %t", err, tc.wantErr)
+			}
+			if tenant.Status.Healthy != tc.wantHealthy {
+				t.Errorf("buildTenantStatus() healthy = %t, want %t", tenant.Status.Healthy, tc.wantHealthy)
+			}
+			// The status is only reset when the RepoSync is not applied.
+			if kept := tenant.Status.RepoSync.Commit != ""; kept != tc.wantErr {
+				t.Errorf("buildTenantStatus() repoSync = %+v, want it kept %t", tenant.Status.RepoSync, tc.wantErr)
+			}
+		})
+	}
+}
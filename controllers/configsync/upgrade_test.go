@@ -0,0 +1,138 @@
+package configsync
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/apimachinery/pkg/util/wait"
+	clientgoscheme "k8s.io/client-go/kubernetes/scheme"
+	"k8s.io/client-go/rest"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/manager"
+	"sigs.k8s.io/kubebuilder-declarative-pattern/mockkubeapiserver"
+	addonloaders "sigs.k8s.io/kubebuilder-declarative-pattern/pkg/patterns/addon/pkg/loaders"
+
+	addonsv1alpha1 "github.com/yuwenma/moss/moss/api/v1alpha1"
+	"github.com/yuwenma/moss/moss/pkg/testutil"
+)
+
+// TestUpgradePrunesDroppedObjects upgrades a ConfigSync between two package versions of testdata/channels,
+// checking that the object shipped only by the first version is deleted.
+func TestUpgradePrunesDroppedObjects(t *testing.T) {
+	ctx := context.Background()
+
+	flagChannel := addonloaders.FlagChannel
+	addonloaders.FlagChannel = "testdata/channels"
+	t.Cleanup(func() { addonloaders.FlagChannel = flagChannel })
+
+	k8s, err := mockkubeapiserver.NewMockKubeAPIServer(":0")
+	if err != nil {
+		t.Fatalf("building mock kube-apiserver: %v", err)
+	}
+	addr, err := k8s.StartServing()
+	if err != nil {
+		t.Fatalf("starting mock kube-apiserver: %v", err)
+	}
+	defer k8s.Stop()
+
+	s := runtime.NewScheme()
+	if err := clientgoscheme.AddToScheme(s); err != nil {
+		t.Fatal(err)
+	}
+	if err := addonsv1alpha1.AddToScheme(s); err != nil {
+		t.Fatal(err)
+	}
+	mgr, err := manager.New(&rest.Config{Host: addr.String()}, manager.Options{Scheme: s, MetricsBindAddress: "0"})
+	if err != nil {
+		t.Fatalf("building manager: %v", err)
+	}
+	dr := &ConfigSyncReconciler{
+		Client: mgr.GetClient(),
+	}
+	if err := dr.SetupWithManager(mgr); err != nil {
+		t.Fatalf("creating reconciler: %v", err)
+	}
+	testutil.CreateObjects(t, mgr.GetClient(), "../../config/crd/bases/configdelivery.anthos.io_configsyncs.yaml")
+
+	cacheCtx, stop := context.WithCancel(ctx)
+	defer stop()
+	go func() {
+		if err := mgr.GetCache().Start(cacheCtx); err != nil {
+			t.Errorf("starting cache: %v", err)
+		}
+	}()
+	mgr.GetCache().WaitForCacheSync(cacheCtx)
+
+	c := mgr.GetAPIReader()
+	key := types.NamespacedName{Name: "configsync-sample"}
+	configSync := &addonsv1alpha1.ConfigSync{}
+	configSync.Name = key.Name
+	configSync.Spec.Version = "1.0.0"
+	if err := mgr.GetClient().Create(ctx, configSync); err != nil {
+		t.Fatalf("creating ConfigSync: %v", err)
+	}
+	reconcile := func() {
+		t.Helper()
+		// The mock apiserver does not serve the status subresource, so writing the status fails with NotFound
+		// after the objects are applied. Any other error fails the test.
+		if _, err := dr.Reconcile(ctx, ctrl.Request{NamespacedName: key}); err != nil && !apierrors.IsNotFound(err) {
+			t.Fatalf("reconciling: %v", err)
+		}
+	}
+	configMap := func(name string) *unstructured.Unstructured {
+		u := &unstructured.Unstructured{}
+		u.SetAPIVersion("v1")
+		u.SetKind("ConfigMap")
+		err := c.Get(ctx, types.NamespacedName{Namespace: configSyncNamespace, Name: name}, u)
+		if apierrors.IsNotFound(err) {
+			return nil
+		}
+		if err != nil {
+			t.Fatalf("reading ConfigMap %s: %v", name, err)
+		}
+		return u
+	}
+
+	reconcile()
+	for _, name := range []string{"kept", "dropped"} {
+		if configMap(name) == nil {
+			t.Fatalf("ConfigMap %s of version 1.0.0 was not applied", name)
+		}
+	}
+
+	if err := c.Get(ctx, key, configSync); err != nil {
+		t.Fatalf("reading ConfigSync: %v", err)
+	}
+	configSync.Spec.Version = "1.1.0"
+	if err := mgr.GetClient().Update(ctx, configSync); err != nil {
+		t.Fatalf("upgrading ConfigSync: %v", err)
+	}
+	// The reconciler reads the ConfigSync from the cache.
+	err = wait.PollImmediate(10*time.Millisecond, 10*time.Second, func() (bool, error) {
+		cached := &addonsv1alpha1.ConfigSync{}
+		if err := mgr.GetClient().Get(ctx, key, cached); err != nil {
+			return false, err
+		}
+		return cached.Spec.Version == "1.1.0", nil
+	})
+	if err != nil {
+		t.Fatalf("waiting for the upgrade to be cached: %v", err)
+	}
+	reconcile()
+
+	kept := configMap("kept")
+	if kept == nil {
+		t.Fatalf("ConfigMap kept was deleted")
+	}
+	if version, _, _ := unstructured.NestedString(kept.Object, "data", "version"); version != "1.1.0" {
+		t.Errorf("ConfigMap kept has version %q, want 1.1.0", version)
+	}
+	if configMap("dropped") != nil {
+		t.Errorf("ConfigMap dropped by version 1.1.0 was not pruned")
+	}
+}
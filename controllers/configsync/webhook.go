@@ -0,0 +1,19 @@
+package configsync
+
+import (
+	ctrl "sigs.k8s.io/controller-runtime"
+
+	addonsv1alpha1 "github.com/yuwenma/moss/moss/api/v1alpha1"
+	"github.com/yuwenma/moss/moss/pkg/webhooks"
+)
+
+//+kubebuilder:webhook:path=/validate-configdelivery-anthos-io-v1alpha1-configsync,mutating=false,failurePolicy=fail,sideEffects=None,groups=configdelivery.anthos.io,resources=configsyncs,verbs=update,versions=v1alpha1,name=vconfigsync.configdelivery.anthos.io,admissionReviewVersions=v1
+
+// SetupWebhookWithManager registers the webhook validating the changes of spec.version against the
+// upgrade paths of the ConfigSync packages. It must be called after SetupWithManager.
+func (r *ConfigSyncReconciler) SetupWebhookWithManager(mgr ctrl.Manager) error {
+	return ctrl.NewWebhookManagedBy(mgr).
+		For(&addonsv1alpha1.ConfigSync{}).
+		WithValidator(&webhooks.UpgradeValidator{Loader: r.loader}).
+		Complete()
+}
@@ -0,0 +1,19 @@
+#!/usr/bin/env bash
+# Signs the channel files for --channel-public-key, writing <channel>.sig next to each of them.
+#
+# The key pair is generated with:
+#   openssl genpkey -algorithm ed25519 -out channel.key
+#   openssl pkey -in channel.key -pubout -out channel.pub
+#
+# The digests listed by the channels, as sha256:<hex>, are printed with:
+#   sha256sum channels/packages/<addon>/<id>/manifest.yaml
+#
+# Usage: hack/sign-channel.sh channel.key channels/stable [channels/rapid ...]
+set -euo pipefail
+
+key="$1"
+shift
+for channel in "$@"; do
+  openssl pkeyutl -sign -inkey "${key}" -rawin -in "${channel}" | base64 -w0 > "${channel}.sig"
+  echo "signed ${channel}"
+done
@@ -1,8 +1,10 @@
 package main
 
 import (
+	"errors"
 	"flag"
 	"os"
+	"strings"
 
 	"github.com/yuwenma/moss/moss/controllers/argocd"
 	"github.com/yuwenma/moss/moss/controllers/configsync"
@@ -19,6 +21,8 @@ import (
 	"sigs.k8s.io/controller-runtime/pkg/log/zap"
 
 	addonsv1alpha1 "github.com/yuwenma/moss/moss/api/v1alpha1"
+	"github.com/yuwenma/moss/moss/pkg/loaders"
+	"github.com/yuwenma/moss/moss/pkg/transforms"
 	//+kubebuilder:scaffold:imports
 )
 
@@ -38,11 +42,21 @@ func main() {
 	var metricsAddr string
 	var enableLeaderElection bool
 	var probeAddr string
+	var tenantClusterRoles string
 	flag.StringVar(&metricsAddr, "metrics-bind-address", ":8080", "The address the metric endpoint binds to.")
 	flag.StringVar(&probeAddr, "health-probe-bind-address", ":8081", "The address the probe endpoint binds to.")
 	flag.BoolVar(&enableLeaderElection, "leader-elect", false,
 		"Enable leader election for controller manager. "+
 			"Enabling this will ensure there is only one active controller manager.")
+	flag.StringVar(&loaders.CacheDir, "channel-cache-dir", loaders.CacheDir,
+		"The directory the channel artifacts pulled from OCI registries (--channel=oci://...) are cached in.")
+	flag.StringVar(&loaders.ChannelPublicKeyFile, "channel-public-key", "",
+		"The PEM encoded ed25519 public key the channels must be signed with. Channels are not verified if unset.")
+	flag.StringVar(&transforms.ImageRegistry, "image-registry", "",
+		"The registry the images of the packages are pulled from instead of their upstream registry, e.g. registry.internal:5000/mirror. "+
+			"On air-gapped clusters --channel is the directory a package bundle is extracted into. Cannot be set with --private-registry.")
+	flag.StringVar(&tenantClusterRoles, "tenant-cluster-roles", strings.Join(configsync.DefaultTenantClusterRoles, ","),
+		"Comma separated ClusterRoles the ConfigSyncTenants may bind to their reconciler. The manager must be granted bind on the roles added.")
 	opts := zap.Options{
 		Development: true,
 	}
@@ -51,6 +65,13 @@ func main() {
 
 	ctrl.SetLogger(zap.New(zap.UseFlagOptions(&opts)))
 
+	// --private-registry is the registry transform of kubebuilder-declarative-pattern, which would rewrite
+	// the images a second time.
+	if transforms.ImageRegistry != "" && flag.Lookup("private-registry").Value.String() != "" {
+		setupLog.Error(errors.New("--image-registry and --private-registry are mutually exclusive"), "invalid flags")
+		os.Exit(1)
+	}
+
 	mgr, err := ctrl.NewManager(ctrl.GetConfigOrDie(), ctrl.Options{
 		Scheme:                 scheme,
 		MetricsBindAddress:     metricsAddr,
@@ -64,20 +85,41 @@ func main() {
 		os.Exit(1)
 	}
 
-	if err = (&argocd.ArgoCDReconciler{
+	argoCDReconciler := &argocd.ArgoCDReconciler{
 		Client: mgr.GetClient(),
 		Scheme: mgr.GetScheme(),
-	}).SetupWithManager(mgr); err != nil {
+	}
+	if err = argoCDReconciler.SetupWithManager(mgr); err != nil {
 		setupLog.Error(err, "unable to create controller", "controller", "ArgoCD")
 		os.Exit(1)
 	}
-	if err = (&configsync.ConfigSyncReconciler{
+	configSyncReconciler := &configsync.ConfigSyncReconciler{
 		Client: mgr.GetClient(),
 		Scheme: mgr.GetScheme(),
-	}).SetupWithManager(mgr); err != nil {
+	}
+	if err = configSyncReconciler.SetupWithManager(mgr); err != nil {
 		setupLog.Error(err, "unable to create controller", "controller", "ConfigSync")
 		os.Exit(1)
 	}
+	if err = (&configsync.ConfigSyncTenantReconciler{
+		Client:       mgr.GetClient(),
+		Scheme:       mgr.GetScheme(),
+		ClusterRoles: strings.Split(tenantClusterRoles, ","),
+	}).SetupWithManager(mgr); err != nil {
+		setupLog.Error(err, "unable to create controller", "controller", "ConfigSyncTenant")
+		os.Exit(1)
+	}
+	// The webhooks need a serving certificate, they can be disabled to run the manager locally.
+	if os.Getenv("ENABLE_WEBHOOKS") != "false" {
+		if err = argoCDReconciler.SetupWebhookWithManager(mgr); err != nil {
+			setupLog.Error(err, "unable to create webhook", "webhook", "ArgoCD")
+			os.Exit(1)
+		}
+		if err = configSyncReconciler.SetupWebhookWithManager(mgr); err != nil {
+			setupLog.Error(err, "unable to create webhook", "webhook", "ConfigSync")
+			os.Exit(1)
+		}
+	}
 	//+kubebuilder:scaffold:builder
 
 	if err := mgr.AddHealthzCheck("healthz", healthz.Ping); err != nil {
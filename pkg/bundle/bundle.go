@@ -0,0 +1,223 @@
+// Package bundle packages addon versions of a channel for clusters which cannot reach the channel
+// repository nor the upstream image registries.
+package bundle
+
+import (
+	"archive/tar"
+	"compress/gzip"
+	"context"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"sigs.k8s.io/kubebuilder-declarative-pattern/pkg/patterns/declarative/pkg/manifest"
+	"sigs.k8s.io/yaml"
+
+	"github.com/yuwenma/moss/moss/pkg/loaders"
+	"github.com/yuwenma/moss/moss/pkg/transforms"
+)
+
+// ImagesFile is the path of the list of the images of the bundled packages, one per line.
+const ImagesFile = "images.txt"
+
+// Options selects the packages of a bundle.
+type Options struct {
+	// ChannelDir is the channels directory the packages are read from.
+	ChannelDir string
+	// Channel is the channel the versions are resolved from, e.g. stable.
+	Channel string
+	// Packages are the addons to bundle, all the addons of the channel if empty.
+	Packages []string
+	// Version pins the version of the single package of Packages instead of resolving the newest
+	// version of the channel.
+	Version string
+}
+
+// Write writes a gzipped tarball of the packages selected by opts to w, and returns the images they
+// reference. The tarball extracts to a channels directory holding the channel restricted to the
+// bundled versions, the packages with their variants and metadata, and ImagesFile. A signed channel
+// must be signed again after it is bundled.
+func Write(ctx context.Context, w io.Writer, opts Options) ([]string, error) {
+	if opts.Version != "" && len(opts.Packages) != 1 {
+		return nil, fmt.Errorf("a version can only be set for a single package, got %d packages", len(opts.Packages))
+	}
+	b, err := os.ReadFile(filepath.Join(opts.ChannelDir, opts.Channel))
+	if err != nil {
+		return nil, fmt.Errorf("error reading channel %q: %w", opts.Channel, err)
+	}
+	channel := &loaders.Channel{}
+	if err := yaml.Unmarshal(b, channel); err != nil {
+		return nil, fmt.Errorf("error parsing channel %q: %w", opts.Channel, err)
+	}
+	versions, err := selectVersions(ctx, channel, opts)
+	if err != nil {
+		return nil, err
+	}
+
+	files := map[string][]byte{}
+	bundled := &loaders.Channel{Manifests: versions}
+	if files[opts.Channel], err = yaml.Marshal(bundled); err != nil {
+		return nil, err
+	}
+	seen := map[string]bool{}
+	for _, v := range versions {
+		if err := addPackage(ctx, files, seen, opts.ChannelDir, v); err != nil {
+			return nil, err
+		}
+	}
+	images := make([]string, 0, len(seen))
+	for image := range seen {
+		images = append(images, image)
+	}
+	sort.Strings(images)
+	files[ImagesFile] = []byte(strings.Join(images, "\n") + "\n")
+
+	if err := writeTarball(w, files); err != nil {
+		return nil, err
+	}
+	return images, nil
+}
+
+// selectVersions returns the versions of the channel to bundle.
+func selectVersions(ctx context.Context, channel *loaders.Channel, opts Options) ([]loaders.ChannelVersion, error) {
+	packages := opts.Packages
+	if len(packages) == 0 {
+		for _, v := range channel.Manifests {
+			if !contains(packages, v.Package) {
+				packages = append(packages, v.Package)
+			}
+		}
+	}
+	var versions []loaders.ChannelVersion
+	for _, p := range packages {
+		if opts.Version != "" {
+			version := loaders.ChannelVersion{Package: p, Version: opts.Version}
+			// Keep the metadata of the version if the channel lists it.
+			for _, v := range channel.Manifests {
+				if v.Package == p && v.Version == opts.Version {
+					version = v
+				}
+			}
+			versions = append(versions, version)
+			continue
+		}
+		// The Kubernetes version of the air-gapped cluster is not known, it is checked when applying.
+		latest, err := channel.Latest(ctx, p, "", time.Now())
+		if err != nil {
+			return nil, err
+		}
+		if latest == nil {
+			return nil, fmt.Errorf("no version of %s promoted to channel %q", p, opts.Channel)
+		}
+		versions = append(versions, *latest)
+	}
+	return versions, nil
+}
+
+// addPackage adds the files of the package version and of its variants, and the images of their
+// manifests.
+func addPackage(ctx context.Context, files map[string][]byte, images map[string]bool, channelDir string, v loaders.ChannelVersion) error {
+	dirs, err := os.ReadDir(filepath.Join(channelDir, "packages", v.Package))
+	if err != nil {
+		return fmt.Errorf("error reading package %s: %w", v.Package, err)
+	}
+	found := false
+	for _, dir := range dirs {
+		if !dir.IsDir() || (dir.Name() != v.Version && !strings.HasPrefix(dir.Name(), v.Version+"-")) {
+			continue
+		}
+		found = true
+		root := filepath.Join(channelDir, "packages", v.Package, dir.Name())
+		if err := filepath.WalkDir(root, func(name string, d fs.DirEntry, err error) error {
+			if err != nil || d.IsDir() {
+				return err
+			}
+			b, err := os.ReadFile(name)
+			if err != nil {
+				return err
+			}
+			rel, err := filepath.Rel(channelDir, name)
+			if err != nil {
+				return err
+			}
+			files[filepath.ToSlash(rel)] = b
+			if filepath.Dir(name) != root {
+				// Subdirectories hold metadata, not manifests.
+				return nil
+			}
+			objects, err := manifest.ParseObjects(ctx, string(b))
+			if err != nil {
+				return fmt.Errorf("error parsing %s: %w", rel, err)
+			}
+			manifestImages, err := transforms.Images(objects)
+			if err != nil {
+				return fmt.Errorf("error reading images of %s: %w", rel, err)
+			}
+			for _, image := range manifestImages {
+				images[image] = true
+			}
+			return nil
+		}); err != nil {
+			return err
+		}
+	}
+	if !found {
+		return fmt.Errorf("package %s %s not found in %s", v.Package, v.Version, channelDir)
+	}
+	return nil
+}
+
+// writeTarball writes the files as a gzipped tarball, in the order of their paths.
+func writeTarball(w io.Writer, files map[string][]byte) error {
+	names := make([]string, 0, len(files))
+	for name := range files {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	gz := gzip.NewWriter(w)
+	tw := tar.NewWriter(gz)
+	dirs := map[string]bool{}
+	for _, name := range names {
+		for dir := path.Dir(name); dir != "." && !dirs[dir]; dir = path.Dir(dir) {
+			dirs[dir] = true
+		}
+	}
+	sortedDirs := make([]string, 0, len(dirs))
+	for dir := range dirs {
+		sortedDirs = append(sortedDirs, dir)
+	}
+	sort.Strings(sortedDirs)
+	for _, dir := range sortedDirs {
+		if err := tw.WriteHeader(&tar.Header{Typeflag: tar.TypeDir, Name: dir + "/", Mode: 0o755}); err != nil {
+			return err
+		}
+	}
+	for _, name := range names {
+		if err := tw.WriteHeader(&tar.Header{Typeflag: tar.TypeReg, Name: name, Mode: 0o644, Size: int64(len(files[name]))}); err != nil {
+			return err
+		}
+		if _, err := tw.Write(files[name]); err != nil {
+			return err
+		}
+	}
+	if err := tw.Close(); err != nil {
+		return err
+	}
+	return gz.Close()
+}
+
+func contains(values []string, value string) bool {
+	for _, v := range values {
+		if v == value {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,112 @@
+package bundle
+
+import (
+	"archive/tar"
+	"bytes"
+	"compress/gzip"
+	"context"
+	"io"
+	"reflect"
+	"strings"
+	"testing"
+
+	"sigs.k8s.io/yaml"
+
+	"github.com/yuwenma/moss/moss/pkg/loaders"
+)
+
+func TestWrite(t *testing.T) {
+	buf := &bytes.Buffer{}
+	images, err := Write(context.Background(), buf, Options{
+		ChannelDir: "../../channels",
+		Channel:    "stable",
+		Packages:   []string{"argocd"},
+		Version:    "2.5.11",
+	})
+	if err != nil {
+		t.Fatalf("Write() error = %v", err)
+	}
+	wantImages := []string{"ghcr.io/dexidp/dex:v2.35.3", "haproxy:2.6.2-alpine", "quay.io/argoproj/argocd:v2.5.11", "redis:7.0.7-alpine"}
+	if !reflect.DeepEqual(images, wantImages) {
+		t.Errorf("Write() images = %v, want %v", images, wantImages)
+	}
+
+	files := readTarball(t, buf)
+	var names []string
+	for name := range files {
+		names = append(names, name)
+	}
+	for _, want := range []string{
+		"stable", ImagesFile,
+		"packages/argocd/2.5.11/manifest.yaml",
+		"packages/argocd/2.5.11/metadata/upgrade.yaml",
+		"packages/argocd/2.5.11-standard/manifest.yaml",
+	} {
+		if _, ok := files[want]; !ok {
+			t.Errorf("bundle files = %v, missing %s", names, want)
+		}
+	}
+	for name := range files {
+		if strings.HasPrefix(name, "packages/configsync/") {
+			t.Errorf("bundle contains %s of another package", name)
+		}
+	}
+	if got := files[ImagesFile]; got != strings.Join(wantImages, "\n")+"\n" {
+		t.Errorf("%s = %q", ImagesFile, got)
+	}
+	channel := &loaders.Channel{}
+	if err := yaml.Unmarshal([]byte(files["stable"]), channel); err != nil {
+		t.Fatal(err)
+	}
+	if len(channel.Manifests) != 1 || channel.Manifests[0].Package != "argocd" || channel.Manifests[0].MinKubernetesVersion != "1.22" {
+		t.Errorf("bundled channel = %+v, want argocd 2.5.11 with its metadata", channel.Manifests)
+	}
+}
+
+func TestWriteErrors(t *testing.T) {
+	tests := []struct {
+		name string
+		opts Options
+		want string
+	}{
+		{name: "version of several packages", opts: Options{Channel: "stable", Version: "1.0.0"}, want: "single package"},
+		{name: "unknown channel", opts: Options{Channel: "nightly"}, want: "error reading channel"},
+		{name: "unknown version", opts: Options{Channel: "stable", Packages: []string{"argocd"}, Version: "9.9.9"}, want: "argocd 9.9.9 not found"},
+		{name: "unknown package", opts: Options{Channel: "stable", Packages: []string{"gatekeeper"}}, want: "no version of gatekeeper"},
+	}
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			tc.opts.ChannelDir = "../../channels"
+			if _, err := Write(context.Background(), io.Discard, tc.opts); err == nil || !strings.Contains(err.Error(), tc.want) {
+				t.Errorf("Write() error = %v, want %q", err, tc.want)
+			}
+		})
+	}
+}
+
+func readTarball(t *testing.T, r io.Reader) map[string]string {
+	t.Helper()
+	gz, err := gzip.NewReader(r)
+	if err != nil {
+		t.Fatal(err)
+	}
+	tr := tar.NewReader(gz)
+	files := map[string]string{}
+	for {
+		header, err := tr.Next()
+		if err == io.EOF {
+			return files
+		}
+		if err != nil {
+			t.Fatal(err)
+		}
+		if header.Typeflag != tar.TypeReg {
+			continue
+		}
+		b, err := io.ReadAll(tr)
+		if err != nil {
+			t.Fatal(err)
+		}
+		files[header.Name] = string(b)
+	}
+}
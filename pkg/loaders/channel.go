@@ -0,0 +1,228 @@
+package loaders
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	semver "github.com/blang/semver/v4"
+	"k8s.io/client-go/discovery"
+	"k8s.io/client-go/rest"
+	"sigs.k8s.io/controller-runtime/pkg/log"
+	addonloaders "sigs.k8s.io/kubebuilder-declarative-pattern/pkg/patterns/addon/pkg/loaders"
+	"sigs.k8s.io/yaml"
+)
+
+// promotionDateLayout is the layout of the promotion dates in the channels.
+const promotionDateLayout = "2006-01-02"
+
+// Channel lists the versions of the addons promoted to a release channel. It is a superset of
+// the channel format of kubebuilder-declarative-pattern.
+type Channel struct {
+	Manifests []ChannelVersion `json:"manifests,omitempty"`
+}
+
+// ChannelVersion is a version of an addon in a channel.
+type ChannelVersion struct {
+	// Package is the name of the addon.
+	Package string `json:"name"`
+	// Version of the addon package.
+	Version string `json:"version"`
+	// Promoted is the date the version is promoted to the channel, e.g. 2023-05-01. A version is
+	// not selected before its promotion date.
+	Promoted string `json:"promoted,omitempty"`
+	// MinKubernetesVersion is the oldest Kubernetes version the version supports, e.g. 1.24.
+	MinKubernetesVersion string `json:"minKubernetesVersion,omitempty"`
+	// Digests are the sha256 digests of the manifest.yaml of the package and of its variants, by
+	// manifest id, e.g. 2.5.11 and 2.5.11-standard. They are required when the channel is signed.
+	Digests map[string]string `json:"digests,omitempty"`
+}
+
+// Latest returns the newest version of the package promoted by now and supporting the
+// Kubernetes version kubeVersion, or nil if there is none. An empty kubeVersion skips the
+// Kubernetes version check.
+func (c *Channel) Latest(ctx context.Context, packageName string, kubeVersion string, now time.Time) (*ChannelVersion, error) {
+	var cluster *semver.Version
+	if kubeVersion != "" {
+		v, err := semver.ParseTolerant(kubeVersion)
+		if err != nil {
+			return nil, fmt.Errorf("error parsing Kubernetes version %q: %w", kubeVersion, err)
+		}
+		// Providers suffix their builds, e.g. v1.27.3-gke.100, which is not older than 1.27.3.
+		v.Pre, v.Build = nil, nil
+		cluster = &v
+	}
+
+	var latest *ChannelVersion
+	var latestVersion semver.Version
+	for i := range c.Manifests {
+		version := &c.Manifests[i]
+		if version.Package != packageName {
+			continue
+		}
+		v, err := semver.ParseTolerant(version.Version)
+		if err != nil {
+			return nil, fmt.Errorf("error parsing version %q of %s: %w", version.Version, packageName, err)
+		}
+		eligible, err := version.eligible(cluster, now)
+		if err != nil {
+			return nil, fmt.Errorf("version %s of %s: %w", version.Version, packageName, err)
+		}
+		if !eligible {
+			log.FromContext(ctx).V(1).Info("skipping version", "package", packageName, "version", version.Version,
+				"promoted", version.Promoted, "minKubernetesVersion", version.MinKubernetesVersion)
+			continue
+		}
+		if latest == nil || v.GT(latestVersion) {
+			latest = version
+			latestVersion = v
+		}
+	}
+	return latest, nil
+}
+
+// eligible reports whether the version is promoted by now and supports the cluster version.
+func (v *ChannelVersion) eligible(cluster *semver.Version, now time.Time) (bool, error) {
+	if v.Promoted != "" {
+		promoted, err := time.Parse(promotionDateLayout, v.Promoted)
+		if err != nil {
+			return false, fmt.Errorf("error parsing promotion date %q: %w", v.Promoted, err)
+		}
+		if now.Before(promoted) {
+			return false, nil
+		}
+	}
+	if v.MinKubernetesVersion != "" && cluster != nil {
+		min, err := semver.ParseTolerant(v.MinKubernetesVersion)
+		if err != nil {
+			return false, fmt.Errorf("error parsing minKubernetesVersion %q: %w", v.MinKubernetesVersion, err)
+		}
+		if cluster.LT(min) {
+			return false, nil
+		}
+	}
+	return true, nil
+}
+
+// parseChannel parses the content of a channel file.
+func parseChannel(b []byte) (*Channel, error) {
+	channel := &Channel{}
+	if err := yaml.Unmarshal(b, channel); err != nil {
+		return nil, fmt.Errorf("error parsing channel: %w", err)
+	}
+	return channel, nil
+}
+
+// fileReader reads a file of a repository, given its slash separated path. It returns an error
+// wrapping os.ErrNotExist if the file does not exist.
+type fileReader func(ctx context.Context, path string) ([]byte, error)
+
+// fsFileReader reads the files of the directory basedir.
+func fsFileReader(basedir string) fileReader {
+	return func(ctx context.Context, path string) ([]byte, error) {
+		b, err := os.ReadFile(filepath.Join(basedir, filepath.FromSlash(path)))
+		if err != nil {
+			return nil, fmt.Errorf("error reading %s: %w", path, err)
+		}
+		return b, nil
+	}
+}
+
+// httpFileReader reads the files under the URL baseURL.
+func httpFileReader(baseURL string) fileReader {
+	return func(ctx context.Context, path string) ([]byte, error) {
+		url := strings.TrimSuffix(baseURL, "/") + "/" + path
+		req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+		if err != nil {
+			return nil, err
+		}
+		response, err := http.DefaultClient.Do(req)
+		if err != nil {
+			return nil, fmt.Errorf("error fetching %q: %w", url, err)
+		}
+		defer response.Body.Close()
+		b, err := io.ReadAll(response.Body)
+		if err != nil {
+			return nil, fmt.Errorf("error reading response for %q: %w", url, err)
+		}
+		switch response.StatusCode {
+		case http.StatusOK:
+			return b, nil
+		case http.StatusNotFound:
+			return nil, fmt.Errorf("error fetching %q: %w", url, os.ErrNotExist)
+		default:
+			return nil, fmt.Errorf("unexpected response code %q fetching %q", response.Status, url)
+		}
+	}
+}
+
+// loadChannel loads the channel name, and checks its signature if the loader has a public key.
+// Repositories without a fileReader, such as git, are read through kubebuilder-declarative-pattern
+// and have no promotion metadata.
+func (l *ManifestLoader) loadChannel(ctx context.Context, name string) (*Channel, error) {
+	if !allowedChannelName(name) {
+		return nil, fmt.Errorf("invalid channel name: %q", name)
+	}
+	if l.files == nil {
+		c, err := l.repo.LoadChannel(ctx, name)
+		if err != nil {
+			return nil, err
+		}
+		return channelOf(c), nil
+	}
+	b, err := l.files(ctx, name)
+	if err != nil {
+		return nil, err
+	}
+	if l.publicKey != nil {
+		if err := l.verifySignature(ctx, name, b); err != nil {
+			return nil, err
+		}
+	}
+	return parseChannel(b)
+}
+
+func channelOf(c *addonloaders.Channel) *Channel {
+	channel := &Channel{}
+	for _, v := range c.Manifests {
+		channel.Manifests = append(channel.Manifests, ChannelVersion{Package: v.Package, Version: v.Version})
+	}
+	return channel
+}
+
+// allowedChannelName keeps channel names to lowercase letters, as kubebuilder-declarative-pattern does,
+// so that they are safe to use as paths.
+func allowedChannelName(name string) bool {
+	if name == "" {
+		return false
+	}
+	for _, r := range name {
+		if r < 'a' || r > 'z' {
+			return false
+		}
+	}
+	return true
+}
+
+// ServerVersionFunc returns the Kubernetes version of the cluster, e.g. v1.27.3.
+type ServerVersionFunc func(ctx context.Context) (string, error)
+
+// DiscoveryServerVersion returns a ServerVersionFunc querying the API server of config.
+func DiscoveryServerVersion(config *rest.Config) (ServerVersionFunc, error) {
+	client, err := discovery.NewDiscoveryClientForConfig(config)
+	if err != nil {
+		return nil, fmt.Errorf("error building discovery client: %w", err)
+	}
+	return func(ctx context.Context) (string, error) {
+		info, err := client.ServerVersion()
+		if err != nil {
+			return "", fmt.Errorf("error reading the Kubernetes version: %w", err)
+		}
+		return info.GitVersion, nil
+	}, nil
+}
@@ -0,0 +1,104 @@
+package loaders
+
+import (
+	"context"
+	"path/filepath"
+	"testing"
+	"time"
+
+	addonsv1alpha1 "github.com/yuwenma/moss/moss/api/v1alpha1"
+)
+
+const testChannel = `manifests:
+- name: configsync
+  version: 1.13.0
+  promoted: "2023-01-02"
+  minKubernetesVersion: "1.21"
+- name: configsync
+  version: 1.15.0
+  promoted: "2023-03-01"
+  minKubernetesVersion: "1.24"
+- name: configsync
+  version: 1.14.1
+  promoted: "2023-02-01"
+  minKubernetesVersion: "1.22"
+- name: configsync
+  version: 1.16.0
+  promoted: "2099-06-01"
+- name: argocd
+  version: 2.5.11
+`
+
+func TestChannelLatest(t *testing.T) {
+	channel, err := parseChannel([]byte(testChannel))
+	if err != nil {
+		t.Fatal(err)
+	}
+	may := time.Date(2023, 5, 1, 0, 0, 0, 0, time.UTC)
+	tests := []struct {
+		name        string
+		packageName string
+		kubeVersion string
+		now         time.Time
+		want        string
+	}{
+		{name: "newest promoted", packageName: "configsync", now: may, want: "1.15.0"},
+		{name: "newest compatible", packageName: "configsync", kubeVersion: "v1.23.4", now: may, want: "1.14.1"},
+		{name: "provider build", packageName: "configsync", kubeVersion: "v1.24.0-gke.100", now: may, want: "1.15.0"},
+		{name: "promoted later", packageName: "configsync", kubeVersion: "v1.27.3", now: time.Date(2099, 6, 1, 0, 0, 0, 0, time.UTC), want: "1.16.0"},
+		{name: "not promoted yet", packageName: "configsync", now: time.Date(2023, 1, 15, 0, 0, 0, 0, time.UTC), want: "1.13.0"},
+		{name: "no compatible version", packageName: "configsync", kubeVersion: "v1.20.0", now: may},
+		{name: "no metadata", packageName: "argocd", kubeVersion: "v1.20.0", now: may, want: "2.5.11"},
+		{name: "unknown package", packageName: "gatekeeper", now: may},
+	}
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			got, err := channel.Latest(context.Background(), tc.packageName, tc.kubeVersion, tc.now)
+			if err != nil {
+				t.Fatalf("Latest() error = %v", err)
+			}
+			var gotVersion string
+			if got != nil {
+				gotVersion = got.Version
+			}
+			if gotVersion != tc.want {
+				t.Errorf("Latest() = %q, want %q", gotVersion, tc.want)
+			}
+		})
+	}
+}
+
+func TestManifestLoaderServerVersion(t *testing.T) {
+	dir := t.TempDir()
+	writeFile(t, filepath.Join(dir, "regular"), testChannel)
+	writeFile(t, filepath.Join(dir, "packages", "configsync", "1.14.1", "manifest.yaml"), "kind: Namespace\n")
+
+	serverVersion := "v1.23.0"
+	loader, err := NewManifestLoader(dir, nil, func(ctx context.Context) (string, error) {
+		return serverVersion, nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	configSync := &addonsv1alpha1.ConfigSync{}
+	configSync.Name = "configsync-sample"
+	configSync.Spec.Channel = "regular"
+
+	got, err := loader.ResolveVersion(context.Background(), configSync)
+	if err != nil {
+		t.Fatalf("ResolveVersion() error = %v", err)
+	}
+	if got != "1.14.1" {
+		t.Errorf("ResolveVersion() = %s on Kubernetes %s, want 1.14.1", got, serverVersion)
+	}
+
+	serverVersion = "v1.20.0"
+	if _, err := loader.ResolveVersion(context.Background(), configSync); err == nil {
+		t.Errorf("ResolveVersion() on Kubernetes %s succeeded, want an error", serverVersion)
+	}
+
+	configSync.Spec.Channel = "../regular"
+	if _, err := loader.ResolveVersion(context.Background(), configSync); err == nil {
+		t.Errorf("ResolveVersion() of channel %s succeeded, want an error", configSync.Spec.Channel)
+	}
+}
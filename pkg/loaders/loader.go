@@ -0,0 +1,330 @@
+package loaders
+
+import (
+	"context"
+	"crypto/ed25519"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"k8s.io/apimachinery/pkg/api/meta"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/log"
+	addonloaders "sigs.k8s.io/kubebuilder-declarative-pattern/pkg/patterns/addon/pkg/loaders"
+	"sigs.k8s.io/kubebuilder-declarative-pattern/pkg/patterns/addon/pkg/utils"
+	"sigs.k8s.io/kubebuilder-declarative-pattern/pkg/patterns/declarative"
+)
+
+// DefaultChannel is the channel used when neither spec.version nor spec.channel is set.
+const DefaultChannel = "stable"
+
+// VariantFunc returns the package variant to load for an addon object. An empty
+// variant loads the default package of the resolved version.
+type VariantFunc func(object runtime.Object) string
+
+// ManifestLoader resolves the version of an addon from its spec or channel and loads
+// the package of that version. Variants of a package are stored next to it as
+// `packages/<addon>/<version>-<variant>`.
+type ManifestLoader struct {
+	repo    addonloaders.Repository
+	files   fileReader
+	variant VariantFunc
+	// serverVersion returns the Kubernetes version the channel versions must support.
+	serverVersion ServerVersionFunc
+	// publicKey verifies the signature of the channels, nil if they are not signed.
+	publicKey ed25519.PublicKey
+
+	mutex    sync.Mutex
+	resolved map[types.NamespacedName]Resolution
+}
+
+// Resolution is the package last loaded for an addon object.
+type Resolution struct {
+	// Version of the package.
+	Version string
+	// Channel the version was resolved from, empty when spec.version is set.
+	Channel string
+	// Digest of the package manifest, e.g. sha256:4f2c...
+	Digest string
+	// Available is the newer version of the channel held back by the upgrade policy.
+	Available string
+	// NextWindow is when the maintenance window of the Available version opens, zero if it is not
+	// waiting for a window.
+	NextWindow time.Time
+	// ProgressDeadline is when Version is rolled back unless it became healthy, zero if there is no
+	// healthy version to roll back to.
+	ProgressDeadline time.Time
+	// RolledBackVersion is the requested version which missed its progress deadline. Version is then the
+	// last healthy version.
+	RolledBackVersion string
+	// RollbackBlocked explains why Version, which missed its progress deadline, is not rolled back to
+	// the last healthy version, e.g. as its upgrade path does not allow downgrades.
+	RollbackBlocked string
+}
+
+var _ declarative.ManifestController = &ManifestLoader{}
+
+// NewManifestLoader builds a ManifestLoader reading from the channel location, which can be
+// a local directory, an http(s) URL, a git repository or an OCI artifact (oci://). variant may be nil. serverVersion may
+// be nil, in which case the Kubernetes version supported by the channel versions is not checked.
+// The channels must be signed with the key of ChannelPublicKeyFile if it is set.
+func NewManifestLoader(channel string, variant VariantFunc, serverVersion ServerVersionFunc) (*ManifestLoader, error) {
+	l := &ManifestLoader{variant: variant, serverVersion: serverVersion, resolved: map[types.NamespacedName]Resolution{}}
+	switch {
+	case strings.HasPrefix(channel, ociScheme):
+		repo, err := newOCIRepository(channel, CacheDir)
+		if err != nil {
+			return nil, err
+		}
+		l.repo = repo
+		l.files = repo.readFile
+	case strings.HasPrefix(channel, "http://") || strings.HasPrefix(channel, "https://"):
+		l.repo = addonloaders.NewHTTPRepository(channel)
+		l.files = httpFileReader(channel)
+	case strings.Contains(channel, "git//") || strings.Contains(channel, ".git"):
+		l.repo = addonloaders.NewGitRepository(channel)
+	default:
+		l.repo = addonloaders.NewFSRepository(channel)
+		l.files = fsFileReader(channel)
+	}
+	if ChannelPublicKeyFile != "" {
+		if l.files == nil {
+			return nil, fmt.Errorf("signed channels are not supported for %q", channel)
+		}
+		publicKey, err := loadPublicKey(ChannelPublicKeyFile)
+		if err != nil {
+			return nil, err
+		}
+		l.publicKey = publicKey
+	}
+	return l, nil
+}
+
+// ResolveVersion returns spec.version if set, otherwise the newest version of the addon in its
+// channel that is promoted and supports the Kubernetes version of the cluster. Addons implementing
+// Upgradable stay on their deployed version until their upgrade policy allows the upgrade.
+func (l *ManifestLoader) ResolveVersion(ctx context.Context, object runtime.Object) (string, error) {
+	resolution, err := l.resolveVersion(ctx, object)
+	return resolution.Version, err
+}
+
+// resolveVersion returns the version of the addon to apply, and the channel it was resolved from.
+// The requested version is rolled back if it missed its progress deadline.
+func (l *ManifestLoader) resolveVersion(ctx context.Context, object runtime.Object) (Resolution, error) {
+	resolution, err := l.requestedVersion(ctx, object)
+	if err != nil {
+		return Resolution{}, err
+	}
+	if upgradable, ok := object.(Upgradable); ok {
+		componentName, err := utils.GetCommonName(object)
+		if err != nil {
+			return Resolution{}, err
+		}
+		l.rollback(ctx, &resolution, componentName, upgradable, time.Now())
+	}
+	return resolution, nil
+}
+
+// requestedVersion returns the version of the addon requested by its spec and upgrade policy.
+func (l *ManifestLoader) requestedVersion(ctx context.Context, object runtime.Object) (Resolution, error) {
+	spec, err := utils.GetCommonSpec(object)
+	if err != nil {
+		return Resolution{}, err
+	}
+	if spec.Version != "" {
+		return Resolution{Version: spec.Version}, nil
+	}
+
+	componentName, err := utils.GetCommonName(object)
+	if err != nil {
+		return Resolution{}, err
+	}
+	channelName := spec.Channel
+	if channelName == "" {
+		channelName = DefaultChannel
+	}
+	channel, err := l.loadChannel(ctx, channelName)
+	if err != nil {
+		return Resolution{}, err
+	}
+	var kubeVersion string
+	if l.serverVersion != nil {
+		if kubeVersion, err = l.serverVersion(ctx); err != nil {
+			return Resolution{}, err
+		}
+	}
+	now := time.Now()
+	upgradable, _ := object.(Upgradable)
+	promotedBy := now
+	if upgradable != nil {
+		// A version has soaked if it was promoted minSoakTime before now.
+		promotedBy = now.Add(-minSoakTime(upgradable))
+	}
+	version, err := channel.Latest(ctx, componentName, kubeVersion, promotedBy)
+	if err != nil {
+		return Resolution{}, err
+	}
+	if version == nil {
+		if kubeVersion != "" {
+			return Resolution{}, fmt.Errorf("could not find a version of %s in channel %q supporting Kubernetes %s", componentName, channelName, kubeVersion)
+		}
+		return Resolution{}, fmt.Errorf("could not find latest version in channel %q", channelName)
+	}
+	log := log.FromContext(ctx).WithValues("channel", channelName).WithValues("kubernetesVersion", kubeVersion)
+	if upgradable == nil {
+		log.WithValues("version", version.Version).Info("resolved version from channel")
+		return Resolution{Version: version.Version, Channel: channelName}, nil
+	}
+
+	u := upgrade{available: version.Version, policy: upgradable.GetUpgradePolicy()}
+	if deployed := upgradable.GetDeployedPackage(); deployed.ResolvedChannel == channelName {
+		u.deployed = deployed.ObservedVersion
+	}
+	resolved, nextWindow, err := u.version(now)
+	if err != nil {
+		return Resolution{}, err
+	}
+	resolution := Resolution{Version: resolved, Channel: channelName, NextWindow: nextWindow}
+	if resolved != u.available && u.pending() {
+		resolution.Available = u.available
+		log.WithValues("version", resolved).WithValues("availableVersion", u.available).WithValues("nextWindow", nextWindow).Info("upgrade held back by the upgrade policy")
+	} else {
+		log.WithValues("version", resolved).Info("resolved version from channel")
+	}
+	return resolution, nil
+}
+
+func (l *ManifestLoader) ResolveManifest(ctx context.Context, object runtime.Object) (map[string]string, error) {
+	componentName, err := utils.GetCommonName(object)
+	if err != nil {
+		return nil, err
+	}
+	resolution, err := l.resolveVersion(ctx, object)
+	if err != nil {
+		return nil, err
+	}
+	id := resolution.Version
+	if l.variant != nil {
+		if variant := l.variant(object); variant != "" {
+			id = id + "-" + variant
+		}
+	}
+	log.FromContext(ctx).WithValues("package", componentName).WithValues("id", id).Info("loading manifest")
+
+	var s map[string]string
+	if l.publicKey != nil {
+		s, err = l.loadVerifiedManifest(ctx, l.channelName(object, resolution), componentName, id)
+	} else {
+		s, err = l.repo.LoadManifest(ctx, componentName, id)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("error loading manifest: %v", err)
+	}
+
+	key, err := objectKey(object)
+	if err != nil {
+		return nil, err
+	}
+	l.mutex.Lock()
+	defer l.mutex.Unlock()
+	resolution.Digest = digest(s, componentName, id)
+	l.resolved[key] = resolution
+	return s, nil
+}
+
+// channelName returns the channel the package of the resolution is verified against: the channel it
+// was resolved from, or spec.channel when spec.version is set.
+func (l *ManifestLoader) channelName(object runtime.Object, resolution Resolution) string {
+	if resolution.Channel != "" {
+		return resolution.Channel
+	}
+	if spec, err := utils.GetCommonSpec(object); err == nil && spec.Channel != "" {
+		return spec.Channel
+	}
+	return DefaultChannel
+}
+
+// RequeueAfter returns when the addon key should be reconciled again: when the maintenance window of
+// a held back upgrade opens, when the progress deadline of an upgrade passes, and at least every
+// ChannelPollInterval for addons following a channel.
+func (l *ManifestLoader) RequeueAfter(key types.NamespacedName, now time.Time) time.Duration {
+	l.mutex.Lock()
+	defer l.mutex.Unlock()
+	resolution, ok := l.resolved[key]
+	if !ok {
+		return 0
+	}
+	var requeueAfter time.Duration
+	if resolution.Channel != "" {
+		requeueAfter = ChannelPollInterval
+	}
+	for _, t := range []time.Time{resolution.NextWindow, resolution.ProgressDeadline} {
+		if t.IsZero() {
+			continue
+		}
+		d := t.Sub(now)
+		if d <= 0 {
+			d = time.Second
+		}
+		if requeueAfter == 0 || d < requeueAfter {
+			requeueAfter = d
+		}
+	}
+	return requeueAfter
+}
+
+// Forget drops the package loaded for the addon key, once the addon is deleted. The key is then no
+// longer requeued.
+func (l *ManifestLoader) Forget(key types.NamespacedName) {
+	l.mutex.Lock()
+	defer l.mutex.Unlock()
+	delete(l.resolved, key)
+}
+
+// Resolved returns the package last loaded for object.
+func (l *ManifestLoader) Resolved(object runtime.Object) (Resolution, bool) {
+	key, err := objectKey(object)
+	if err != nil {
+		return Resolution{}, false
+	}
+	l.mutex.Lock()
+	defer l.mutex.Unlock()
+	resolution, ok := l.resolved[key]
+	return resolution, ok
+}
+
+func objectKey(object runtime.Object) (types.NamespacedName, error) {
+	accessor, err := meta.Accessor(object)
+	if err != nil {
+		return types.NamespacedName{}, err
+	}
+	return types.NamespacedName{Namespace: accessor.GetNamespace(), Name: accessor.GetName()}, nil
+}
+
+// digest returns the sha256 digest of the manifest files of package id, in the order of their names.
+// The names are relative to the package directory, as the repositories key the files by different paths,
+// e.g. channels/packages/<addon>/<id>/manifest.yaml for a local directory.
+func digest(manifests map[string]string, componentName, id string) string {
+	dir := "packages/" + componentName + "/" + id + "/"
+	files := make(map[string]string, len(manifests))
+	names := make([]string, 0, len(manifests))
+	for name, content := range manifests {
+		if i := strings.Index(name, dir); i >= 0 {
+			name = name[i+len(dir):]
+		}
+		files[name] = content
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	h := sha256.New()
+	for _, name := range names {
+		fmt.Fprintf(h, "%s\x00%s\x00", name, files[name])
+	}
+	return "sha256:" + hex.EncodeToString(h.Sum(nil))
+}
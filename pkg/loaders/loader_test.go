@@ -0,0 +1,109 @@
+package loaders
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"k8s.io/apimachinery/pkg/types"
+
+	addonsv1alpha1 "github.com/yuwenma/moss/moss/api/v1alpha1"
+)
+
+func TestManifestLoaderResolved(t *testing.T) {
+	dir := t.TempDir()
+	writeFile(t, filepath.Join(dir, "stable"), "manifests:\n- name: configsync\n  version: 1.14.1\n")
+	writeFile(t, filepath.Join(dir, "packages", "configsync", "1.14.1", "manifest.yaml"), "kind: Namespace\n")
+
+	loader, err := NewManifestLoader(dir, nil, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	ctx := context.Background()
+
+	configSync := &addonsv1alpha1.ConfigSync{}
+	configSync.Name = "configsync-sample"
+	if _, ok := loader.Resolved(configSync); ok {
+		t.Errorf("Resolved() before loading the manifest, want not found")
+	}
+	if _, err := loader.ResolveManifest(ctx, configSync); err != nil {
+		t.Fatalf("ResolveManifest() error = %v", err)
+	}
+	got, ok := loader.Resolved(configSync)
+	if !ok {
+		t.Fatalf("Resolved() not found after loading the manifest")
+	}
+	if got.Version != "1.14.1" || got.Channel != DefaultChannel {
+		t.Errorf("Resolved() = %+v, want version 1.14.1 from channel %s", got, DefaultChannel)
+	}
+	if !strings.HasPrefix(got.Digest, "sha256:") {
+		t.Errorf("Resolved().Digest = %s, want a sha256 digest", got.Digest)
+	}
+	fromChannel := got
+
+	configSync.Spec.Version = "1.14.1"
+	if _, err := loader.ResolveManifest(ctx, configSync); err != nil {
+		t.Fatalf("ResolveManifest() error = %v", err)
+	}
+	got, _ = loader.Resolved(configSync)
+	if got.Channel != "" {
+		t.Errorf("Resolved().Channel = %s with spec.version set, want empty", got.Channel)
+	}
+	if got.Digest != fromChannel.Digest {
+		t.Errorf("Resolved().Digest = %s, want %s for the same package", got.Digest, fromChannel.Digest)
+	}
+}
+
+func TestManifestLoaderForget(t *testing.T) {
+	dir := t.TempDir()
+	writeFile(t, filepath.Join(dir, "stable"), "manifests:\n- name: configsync\n  version: 1.14.1\n")
+	writeFile(t, filepath.Join(dir, "packages", "configsync", "1.14.1", "manifest.yaml"), "kind: Namespace\n")
+
+	loader, err := NewManifestLoader(dir, nil, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	configSync := &addonsv1alpha1.ConfigSync{}
+	configSync.Name = "configsync-sample"
+	if _, err := loader.ResolveManifest(context.Background(), configSync); err != nil {
+		t.Fatalf("ResolveManifest() error = %v", err)
+	}
+	key := types.NamespacedName{Name: configSync.Name}
+	if requeue := loader.RequeueAfter(key, time.Now()); requeue == 0 {
+		t.Errorf("RequeueAfter() = 0 for an addon following a channel, want %s", ChannelPollInterval)
+	}
+
+	// The ConfigSync is deleted.
+	loader.Forget(key)
+	if _, ok := loader.Resolved(configSync); ok {
+		t.Errorf("Resolved() after Forget(), want not found")
+	}
+	if requeue := loader.RequeueAfter(key, time.Now()); requeue != 0 {
+		t.Errorf("RequeueAfter() = %s after Forget(), want 0", requeue)
+	}
+}
+
+func TestDigest(t *testing.T) {
+	// The same package loaded from a local directory, an OCI artifact or a signed channel.
+	want := digest(map[string]string{"packages/configsync/1.14.1/manifest.yaml": "kind: Namespace\n"}, "configsync", "1.14.1")
+	got := digest(map[string]string{"channels/packages/configsync/1.14.1/manifest.yaml": "kind: Namespace\n"}, "configsync", "1.14.1")
+	if got != want {
+		t.Errorf("digest() = %s for a local directory, want %s", got, want)
+	}
+	if other := digest(map[string]string{"packages/configsync/1.14.1/manifest.yaml": "kind: ConfigMap\n"}, "configsync", "1.14.1"); other == want {
+		t.Errorf("digest() = %s for different contents, want a different digest", other)
+	}
+}
+
+func writeFile(t *testing.T, path, content string) {
+	t.Helper()
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(path, []byte(content), 0o644); err != nil {
+		t.Fatal(err)
+	}
+}
@@ -0,0 +1,457 @@
+package loaders
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"net/url"
+	"os"
+	"path"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"sync"
+	"time"
+
+	"sigs.k8s.io/controller-runtime/pkg/log"
+	addonloaders "sigs.k8s.io/kubebuilder-declarative-pattern/pkg/patterns/addon/pkg/loaders"
+	"sigs.k8s.io/yaml"
+)
+
+// CacheDir is the directory the artifacts pulled from OCI registries are cached in, by digest.
+var CacheDir = filepath.Join(os.TempDir(), "moss-cache")
+
+const (
+	ociScheme            = "oci://"
+	ociManifestMediaType = "application/vnd.oci.image.manifest.v1+json"
+	// ociTitleAnnotation is the path of the file held by a layer, as set by `oras push`.
+	ociTitleAnnotation = "org.opencontainers.image.title"
+)
+
+var ociDigestRegexp = regexp.MustCompile(`^sha256:[a-f0-9]{64}$`)
+
+// ociReference is a reference to an OCI artifact, e.g. oci://registry.example.com/moss/channels:v1 or,
+// pinned to a digest, oci://registry.example.com/moss/channels@sha256:4f2c...
+type ociReference struct {
+	registry   string
+	repository string
+	tag        string
+	digest     string
+}
+
+func parseOCIReference(s string) (ociReference, error) {
+	registry, rest, ok := strings.Cut(strings.TrimPrefix(s, ociScheme), "/")
+	if !ok || registry == "" || rest == "" {
+		return ociReference{}, fmt.Errorf("invalid OCI reference %q, expected oci://<registry>/<repository>[:<tag>|@<digest>]", s)
+	}
+	ref := ociReference{registry: registry}
+	if repository, digest, ok := strings.Cut(rest, "@"); ok {
+		if !ociDigestRegexp.MatchString(digest) {
+			return ociReference{}, fmt.Errorf("invalid digest %q in OCI reference %q, expected sha256:<hex>", digest, s)
+		}
+		ref.repository, ref.digest = repository, digest
+	} else if i := strings.LastIndex(rest, ":"); i >= 0 {
+		ref.repository, ref.tag = rest[:i], rest[i+1:]
+	} else {
+		ref.repository, ref.tag = rest, "latest"
+	}
+	if ref.repository == "" || (ref.digest == "" && ref.tag == "") {
+		return ociReference{}, fmt.Errorf("invalid OCI reference %q", s)
+	}
+	return ref, nil
+}
+
+func (r ociReference) String() string {
+	if r.digest != "" {
+		return ociScheme + r.registry + "/" + r.repository + "@" + r.digest
+	}
+	return ociScheme + r.registry + "/" + r.repository + ":" + r.tag
+}
+
+// url returns the URL of a manifest or blob of the repository. Registries on the loopback interface
+// are reached over plain HTTP.
+func (r ociReference) url(kind, reference string) string {
+	scheme := "https"
+	host := r.registry
+	if h, _, err := net.SplitHostPort(host); err == nil {
+		host = h
+	}
+	if ip := net.ParseIP(host); host == "localhost" || (ip != nil && ip.IsLoopback()) {
+		scheme = "http"
+	}
+	return fmt.Sprintf("%s://%s/v2/%s/%s/%s", scheme, r.registry, r.repository, kind, reference)
+}
+
+type ociDescriptor struct {
+	MediaType   string            `json:"mediaType,omitempty"`
+	Digest      string            `json:"digest"`
+	Size        int64             `json:"size"`
+	Annotations map[string]string `json:"annotations,omitempty"`
+}
+
+type ociManifest struct {
+	SchemaVersion int             `json:"schemaVersion"`
+	MediaType     string          `json:"mediaType,omitempty"`
+	Config        ociDescriptor   `json:"config"`
+	Layers        []ociDescriptor `json:"layers"`
+}
+
+// ociRepository is a Repository stored as an OCI artifact, with one layer per file titled with its path,
+// e.g. `stable` or `packages/argocd/2.5.11/manifest.yaml`, as pushed by `oras push` from the channels
+// directory. A tag is resolved again every ChannelPollInterval, and the last resolved artifact is used
+// while the registry is unreachable. The manifests and blobs are verified against their digest and
+// cached on disk.
+type ociRepository struct {
+	ref    ociReference
+	cache  blobCache
+	client *http.Client
+
+	// tokenMutex guards token, which is also used by the blobs fetched outside of mutex.
+	tokenMutex sync.Mutex
+	token      string
+
+	mutex      sync.Mutex
+	digest     string
+	resolvedAt time.Time
+	files      map[string]ociDescriptor
+}
+
+var _ addonloaders.Repository = &ociRepository{}
+
+func newOCIRepository(location, cacheDir string) (*ociRepository, error) {
+	ref, err := parseOCIReference(location)
+	if err != nil {
+		return nil, err
+	}
+	return &ociRepository{ref: ref, cache: blobCache{dir: cacheDir}, client: http.DefaultClient}, nil
+}
+
+func (r *ociRepository) LoadChannel(ctx context.Context, name string) (*addonloaders.Channel, error) {
+	b, err := r.readFile(ctx, name)
+	if err != nil {
+		return nil, err
+	}
+	channel := &addonloaders.Channel{}
+	if err := yaml.Unmarshal(b, channel); err != nil {
+		return nil, fmt.Errorf("error parsing channel %s: %w", name, err)
+	}
+	return channel, nil
+}
+
+// LoadManifest returns the files of the package directory, keyed by path. As for directories, the files
+// of its subdirectories are not part of the manifest.
+func (r *ociRepository) LoadManifest(ctx context.Context, packageName string, id string) (map[string]string, error) {
+	if !allowedManifestID(packageName) || !allowedManifestID(id) {
+		return nil, fmt.Errorf("invalid package %q or manifest id %q", packageName, id)
+	}
+	files, err := r.artifact(ctx)
+	if err != nil {
+		return nil, err
+	}
+	dir := path.Join("packages", packageName, id) + "/"
+	result := map[string]string{}
+	for name := range files {
+		if !strings.HasPrefix(name, dir) || strings.Contains(strings.TrimPrefix(name, dir), "/") {
+			continue
+		}
+		b, err := r.readFile(ctx, name)
+		if err != nil {
+			return nil, err
+		}
+		result[name] = string(b)
+	}
+	if len(result) == 0 {
+		return nil, fmt.Errorf("package %s %s not found in %s", packageName, id, r.ref)
+	}
+	return result, nil
+}
+
+// readFile is the fileReader of the repository.
+func (r *ociRepository) readFile(ctx context.Context, name string) ([]byte, error) {
+	files, err := r.artifact(ctx)
+	if err != nil {
+		return nil, err
+	}
+	desc, ok := files[name]
+	if !ok {
+		return nil, fmt.Errorf("error reading %s from %s: %w", name, r.ref, os.ErrNotExist)
+	}
+	return r.blob(ctx, "blobs", desc.Digest)
+}
+
+// artifact returns the file layers of the artifact, by path.
+func (r *ociRepository) artifact(ctx context.Context) (map[string]ociDescriptor, error) {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+	if r.files != nil && (r.ref.digest != "" || time.Since(r.resolvedAt) < ChannelPollInterval) {
+		return r.files, nil
+	}
+
+	log := log.FromContext(ctx).WithValues("artifact", r.ref.String())
+	digest := r.ref.digest
+	if digest == "" {
+		resolved, err := r.resolveTag(ctx)
+		switch {
+		case err == nil:
+			digest = resolved
+		case r.files != nil:
+			log.Error(err, "error resolving tag, using the last resolved artifact", "digest", r.digest)
+			r.resolvedAt = time.Now()
+			return r.files, nil
+		default:
+			cached, ok := r.cache.tag(r.ref)
+			if !ok {
+				return nil, err
+			}
+			log.Error(err, "error resolving tag, using the cached artifact", "digest", cached)
+			digest = cached
+		}
+	}
+
+	b, err := r.blob(ctx, "manifests", digest)
+	if err != nil {
+		return nil, err
+	}
+	manifest := &ociManifest{}
+	if err := json.Unmarshal(b, manifest); err != nil {
+		return nil, fmt.Errorf("error parsing manifest %s of %s: %w", digest, r.ref, err)
+	}
+	files := map[string]ociDescriptor{}
+	for _, layer := range manifest.Layers {
+		name := strings.TrimPrefix(layer.Annotations[ociTitleAnnotation], "channels/")
+		if name == "" {
+			continue
+		}
+		if clean := path.Clean(name); clean != name || path.IsAbs(name) || strings.HasPrefix(name, "../") {
+			return nil, fmt.Errorf("invalid file path %q in %s", name, r.ref)
+		}
+		if !ociDigestRegexp.MatchString(layer.Digest) {
+			return nil, fmt.Errorf("unsupported digest %q of %s in %s", layer.Digest, name, r.ref)
+		}
+		files[name] = layer
+	}
+	if r.digest != digest {
+		log.Info("loaded artifact", "digest", digest, "files", len(files))
+	}
+	if r.ref.digest == "" {
+		if err := r.cache.setTag(r.ref, digest); err != nil {
+			log.Error(err, "error caching the digest of the tag")
+		}
+	}
+	r.digest, r.resolvedAt, r.files = digest, time.Now(), files
+	return files, nil
+}
+
+// resolveTag returns the digest of the manifest the tag points to.
+func (r *ociRepository) resolveTag(ctx context.Context) (string, error) {
+	b, header, err := r.get(ctx, r.ref.url("manifests", r.ref.tag))
+	if err != nil {
+		return "", err
+	}
+	digest := header.Get("Docker-Content-Digest")
+	if digest == "" {
+		digest = sha256Digest(b)
+	}
+	if err := r.cache.put(digest, b); err != nil {
+		return "", fmt.Errorf("manifest of %s: %w", r.ref, err)
+	}
+	return digest, nil
+}
+
+// blob returns the manifest or blob with the digest, from the cache if present.
+func (r *ociRepository) blob(ctx context.Context, kind, digest string) ([]byte, error) {
+	if b, ok := r.cache.get(digest); ok {
+		return b, nil
+	}
+	b, _, err := r.get(ctx, r.ref.url(kind, digest))
+	if err != nil {
+		return nil, err
+	}
+	if err := r.cache.put(digest, b); err != nil {
+		return nil, fmt.Errorf("%s of %s: %w", kind, r.ref, err)
+	}
+	return b, nil
+}
+
+// get fetches url, authenticating with an anonymous bearer token if the registry requires one.
+func (r *ociRepository) get(ctx context.Context, url string) ([]byte, http.Header, error) {
+	b, header, status, err := r.do(ctx, url)
+	if err != nil {
+		return nil, nil, err
+	}
+	if status == http.StatusUnauthorized {
+		if err := r.authenticate(ctx, header.Get("WWW-Authenticate")); err != nil {
+			return nil, nil, fmt.Errorf("error authenticating to %s: %w", r.ref.registry, err)
+		}
+		if b, header, status, err = r.do(ctx, url); err != nil {
+			return nil, nil, err
+		}
+	}
+	switch status {
+	case http.StatusOK:
+		return b, header, nil
+	case http.StatusNotFound:
+		return nil, nil, fmt.Errorf("error fetching %q: %w", url, os.ErrNotExist)
+	default:
+		return nil, nil, fmt.Errorf("unexpected response code %d fetching %q", status, url)
+	}
+}
+
+func (r *ociRepository) do(ctx context.Context, url string) ([]byte, http.Header, int, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	if err != nil {
+		return nil, nil, 0, err
+	}
+	req.Header.Set("Accept", ociManifestMediaType)
+	r.tokenMutex.Lock()
+	token := r.token
+	r.tokenMutex.Unlock()
+	if token != "" {
+		req.Header.Set("Authorization", "Bearer "+token)
+	}
+	response, err := r.client.Do(req)
+	if err != nil {
+		return nil, nil, 0, fmt.Errorf("error fetching %q: %w", url, err)
+	}
+	defer response.Body.Close()
+	b, err := io.ReadAll(response.Body)
+	if err != nil {
+		return nil, nil, 0, fmt.Errorf("error reading response for %q: %w", url, err)
+	}
+	return b, response.Header, response.StatusCode, nil
+}
+
+var challengeParamRegexp = regexp.MustCompile(`(\w+)="([^"]*)"`)
+
+// authenticate gets an anonymous token from the realm of a `Bearer realm="...",service="...",scope="..."`
+// challenge.
+func (r *ociRepository) authenticate(ctx context.Context, challenge string) error {
+	if !strings.HasPrefix(challenge, "Bearer ") {
+		return fmt.Errorf("unsupported challenge %q", challenge)
+	}
+	params := map[string]string{}
+	for _, m := range challengeParamRegexp.FindAllStringSubmatch(challenge, -1) {
+		params[m[1]] = m[2]
+	}
+	realm, err := url.Parse(params["realm"])
+	if err != nil || realm.Host == "" {
+		return fmt.Errorf("invalid realm in challenge %q", challenge)
+	}
+	query := realm.Query()
+	for _, key := range []string{"service", "scope"} {
+		if params[key] != "" {
+			query.Set(key, params[key])
+		}
+	}
+	realm.RawQuery = query.Encode()
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, realm.String(), nil)
+	if err != nil {
+		return err
+	}
+	response, err := r.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer response.Body.Close()
+	if response.StatusCode != http.StatusOK {
+		return fmt.Errorf("unexpected response code %d fetching a token from %s", response.StatusCode, realm.Host)
+	}
+	token := struct {
+		Token       string `json:"token"`
+		AccessToken string `json:"access_token"`
+	}{}
+	if err := json.NewDecoder(response.Body).Decode(&token); err != nil {
+		return fmt.Errorf("error parsing token: %w", err)
+	}
+	r.tokenMutex.Lock()
+	defer r.tokenMutex.Unlock()
+	r.token = token.Token
+	if r.token == "" {
+		r.token = token.AccessToken
+	}
+	return nil
+}
+
+// blobCache is a content-addressed cache of manifests and blobs, stored as `blobs/sha256/<hex>` under
+// dir. The digests the tags last resolved to are stored under `tags`.
+type blobCache struct {
+	dir string
+}
+
+func (c blobCache) path(digest string) string {
+	algorithm, hex, _ := strings.Cut(digest, ":")
+	return filepath.Join(c.dir, "blobs", algorithm, hex)
+}
+
+// get returns the content of digest, if it is cached and not corrupted.
+func (c blobCache) get(digest string) ([]byte, bool) {
+	if !ociDigestRegexp.MatchString(digest) {
+		return nil, false
+	}
+	b, err := os.ReadFile(c.path(digest))
+	if err != nil || sha256Digest(b) != digest {
+		return nil, false
+	}
+	return b, true
+}
+
+// put verifies that b has the digest, and caches it.
+func (c blobCache) put(digest string, b []byte) error {
+	if !ociDigestRegexp.MatchString(digest) {
+		return fmt.Errorf("unsupported digest %q", digest)
+	}
+	if actual := sha256Digest(b); actual != digest {
+		return fmt.Errorf("digest mismatch: expected %s, got %s", digest, actual)
+	}
+	return writeFileAtomic(c.path(digest), b)
+}
+
+func (c blobCache) tagPath(ref ociReference) string {
+	return filepath.Join(c.dir, "tags", strings.TrimPrefix(sha256Digest([]byte(ref.String())), "sha256:"))
+}
+
+// tag returns the digest the tag of ref last resolved to.
+func (c blobCache) tag(ref ociReference) (string, bool) {
+	b, err := os.ReadFile(c.tagPath(ref))
+	if err != nil {
+		return "", false
+	}
+	digest := strings.TrimSpace(string(b))
+	return digest, ociDigestRegexp.MatchString(digest)
+}
+
+func (c blobCache) setTag(ref ociReference, digest string) error {
+	return writeFileAtomic(c.tagPath(ref), []byte(digest))
+}
+
+func writeFileAtomic(name string, b []byte) error {
+	if err := os.MkdirAll(filepath.Dir(name), 0o755); err != nil {
+		return err
+	}
+	f, err := os.CreateTemp(filepath.Dir(name), ".tmp-")
+	if err != nil {
+		return err
+	}
+	_, err = f.Write(b)
+	if closeErr := f.Close(); err == nil {
+		err = closeErr
+	}
+	if err == nil {
+		err = os.Rename(f.Name(), name)
+	}
+	if err != nil {
+		os.Remove(f.Name())
+	}
+	return err
+}
+
+func sha256Digest(b []byte) string {
+	h := sha256.Sum256(b)
+	return "sha256:" + hex.EncodeToString(h[:])
+}
@@ -0,0 +1,264 @@
+package loaders
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+)
+
+// testRegistry is an in-process OCI registry serving a single repository.
+type testRegistry struct {
+	mutex     sync.Mutex
+	manifests map[string][]byte
+	blobs     map[string][]byte
+	tags      map[string]string
+	// token is required as bearer token if set, and served anonymously at /token.
+	token string
+}
+
+func newTestRegistry() *testRegistry {
+	return &testRegistry{manifests: map[string][]byte{}, blobs: map[string][]byte{}, tags: map[string]string{}}
+}
+
+// push stores files as an artifact with one layer per file, tags it and returns its digest.
+func (r *testRegistry) push(t *testing.T, tag string, files map[string]string) string {
+	t.Helper()
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+	manifest := ociManifest{SchemaVersion: 2, MediaType: ociManifestMediaType}
+	config := []byte("{}")
+	r.blobs[sha256Digest(config)] = config
+	manifest.Config = ociDescriptor{MediaType: "application/vnd.oci.image.config.v1+json", Digest: sha256Digest(config), Size: 2}
+	for name, content := range files {
+		r.blobs[sha256Digest([]byte(content))] = []byte(content)
+		manifest.Layers = append(manifest.Layers, ociDescriptor{
+			MediaType:   "application/vnd.oci.image.layer.v1.tar",
+			Digest:      sha256Digest([]byte(content)),
+			Size:        int64(len(content)),
+			Annotations: map[string]string{ociTitleAnnotation: name},
+		})
+	}
+	b, err := json.Marshal(manifest)
+	if err != nil {
+		t.Fatal(err)
+	}
+	digest := sha256Digest(b)
+	r.manifests[digest] = b
+	r.tags[tag] = digest
+	return digest
+}
+
+func (r *testRegistry) tag(tag, digest string) {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+	r.tags[tag] = digest
+}
+
+func (r *testRegistry) setBlob(digest, content string) {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+	r.blobs[digest] = []byte(content)
+}
+
+func (r *testRegistry) setToken(token string) {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+	r.token = token
+}
+
+func (r *testRegistry) ServeHTTP(w http.ResponseWriter, req *http.Request) {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+	if req.URL.Path == "/token" {
+		json.NewEncoder(w).Encode(map[string]string{"token": r.token})
+		return
+	}
+	if r.token != "" && req.Header.Get("Authorization") != "Bearer "+r.token {
+		w.Header().Set("WWW-Authenticate", `Bearer realm="http://`+req.Host+`/token",service="test",scope="repository:moss/channels:pull"`)
+		w.WriteHeader(http.StatusUnauthorized)
+		return
+	}
+	parts := strings.Split(strings.TrimPrefix(req.URL.Path, "/v2/moss/channels/"), "/")
+	if len(parts) != 2 {
+		http.NotFound(w, req)
+		return
+	}
+	switch parts[0] {
+	case "manifests":
+		digest := parts[1]
+		if d, ok := r.tags[digest]; ok {
+			digest = d
+		}
+		b, ok := r.manifests[digest]
+		if !ok {
+			http.NotFound(w, req)
+			return
+		}
+		w.Header().Set("Content-Type", ociManifestMediaType)
+		w.Header().Set("Docker-Content-Digest", digest)
+		w.Write(b)
+	case "blobs":
+		b, ok := r.blobs[parts[1]]
+		if !ok {
+			http.NotFound(w, req)
+			return
+		}
+		w.Write(b)
+	default:
+		http.NotFound(w, req)
+	}
+}
+
+func TestOCIRepository(t *testing.T) {
+	registry := newTestRegistry()
+	v1 := registry.push(t, "v1", map[string]string{
+		"channels/stable": "manifests:\n- name: configsync\n  version: 1.14.1\n",
+		"channels/packages/configsync/1.14.1/manifest.yaml":         "kind: Namespace\n",
+		"channels/packages/configsync/1.14.1/metadata/upgrade.yaml": "from: [\">=1.12.0\"]\n",
+	})
+	registry.push(t, "v2", map[string]string{
+		"channels/stable": "manifests:\n- name: configsync\n  version: 1.15.0\n",
+	})
+	server := httptest.NewServer(registry)
+	defer server.Close()
+	host := strings.TrimPrefix(server.URL, "http://")
+	cacheDir := t.TempDir()
+	ctx := context.Background()
+
+	repo, err := newOCIRepository("oci://"+host+"/moss/channels:v1", cacheDir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	channel, err := repo.LoadChannel(ctx, "stable")
+	if err != nil {
+		t.Fatalf("LoadChannel() error = %v", err)
+	}
+	if len(channel.Manifests) != 1 || channel.Manifests[0].Version != "1.14.1" {
+		t.Errorf("LoadChannel() = %+v, want configsync 1.14.1", channel.Manifests)
+	}
+	manifest, err := repo.LoadManifest(ctx, "configsync", "1.14.1")
+	if err != nil {
+		t.Fatalf("LoadManifest() error = %v", err)
+	}
+	if len(manifest) != 1 || manifest["packages/configsync/1.14.1/manifest.yaml"] != "kind: Namespace\n" {
+		t.Errorf("LoadManifest() = %v, want only manifest.yaml", manifest)
+	}
+	if _, err := repo.readFile(ctx, "rapid"); !errors.Is(err, os.ErrNotExist) {
+		t.Errorf("readFile() of a missing file error = %v, want not exist", err)
+	}
+
+	t.Run("pinned digest", func(t *testing.T) {
+		repo, err := newOCIRepository("oci://"+host+"/moss/channels@"+v1, t.TempDir())
+		if err != nil {
+			t.Fatal(err)
+		}
+		// Moving the tag does not change a pinned artifact.
+		registry.push(t, "v1", map[string]string{"channels/stable": "manifests: []\n"})
+		defer registry.tag("v1", v1)
+		channel, err := repo.LoadChannel(ctx, "stable")
+		if err != nil {
+			t.Fatalf("LoadChannel() error = %v", err)
+		}
+		if len(channel.Manifests) != 1 || channel.Manifests[0].Version != "1.14.1" {
+			t.Errorf("LoadChannel() = %+v, want configsync 1.14.1", channel.Manifests)
+		}
+	})
+
+	t.Run("cached", func(t *testing.T) {
+		// A new repository with the same cache loads the artifact without the registry.
+		offline, err := newOCIRepository("oci://"+host+"/moss/channels:v1", cacheDir)
+		if err != nil {
+			t.Fatal(err)
+		}
+		offline.client = &http.Client{Transport: roundTripperFunc(func(*http.Request) (*http.Response, error) {
+			return nil, os.ErrDeadlineExceeded
+		})}
+		if _, err := offline.LoadManifest(ctx, "configsync", "1.14.1"); err != nil {
+			t.Errorf("LoadManifest() error = %v with a cached artifact", err)
+		}
+	})
+
+	t.Run("corrupted", func(t *testing.T) {
+		repo, err := newOCIRepository("oci://"+host+"/moss/channels:v1", t.TempDir())
+		if err != nil {
+			t.Fatal(err)
+		}
+		digest := sha256Digest([]byte("kind: Namespace\n"))
+		registry.setBlob(digest, "kind: Secret\n")
+		defer registry.setBlob(digest, "kind: Namespace\n")
+		if _, err := repo.LoadManifest(ctx, "configsync", "1.14.1"); err == nil || !strings.Contains(err.Error(), "digest mismatch") {
+			t.Errorf("LoadManifest() error = %v, want digest mismatch", err)
+		}
+	})
+
+	t.Run("authenticated", func(t *testing.T) {
+		registry.setToken("secret")
+		defer registry.setToken("")
+		repo, err := newOCIRepository("oci://"+host+"/moss/channels:v1", t.TempDir())
+		if err != nil {
+			t.Fatal(err)
+		}
+		// The blobs are fetched concurrently, outside of the lock of the artifact.
+		var wg sync.WaitGroup
+		for i := 0; i < 4; i++ {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				if _, err := repo.LoadManifest(ctx, "configsync", "1.14.1"); err != nil {
+					t.Errorf("LoadManifest() error = %v", err)
+				}
+			}()
+		}
+		wg.Wait()
+	})
+
+	t.Run("manifest loader", func(t *testing.T) {
+		defer func(dir string) { CacheDir = dir }(CacheDir)
+		CacheDir = t.TempDir()
+		loader, err := NewManifestLoader("oci://"+host+"/moss/channels:v2", nil, nil)
+		if err != nil {
+			t.Fatal(err)
+		}
+		channel, err := loader.loadChannel(ctx, "stable")
+		if err != nil {
+			t.Fatalf("loadChannel() error = %v", err)
+		}
+		if len(channel.Manifests) != 1 || channel.Manifests[0].Version != "1.15.0" {
+			t.Errorf("loadChannel() = %+v, want configsync 1.15.0", channel.Manifests)
+		}
+		if _, err := os.Stat(filepath.Join(CacheDir, "blobs", "sha256")); err != nil {
+			t.Errorf("artifact not cached: %v", err)
+		}
+	})
+}
+
+func TestParseOCIReference(t *testing.T) {
+	tests := []struct {
+		ref     string
+		want    ociReference
+		wantErr bool
+	}{
+		{ref: "oci://gcr.io/moss/channels", want: ociReference{registry: "gcr.io", repository: "moss/channels", tag: "latest"}},
+		{ref: "oci://localhost:5000/channels:v1", want: ociReference{registry: "localhost:5000", repository: "channels", tag: "v1"}},
+		{ref: "oci://gcr.io/channels@sha256:" + strings.Repeat("a", 64), want: ociReference{registry: "gcr.io", repository: "channels", digest: "sha256:" + strings.Repeat("a", 64)}},
+		{ref: "oci://gcr.io/channels@sha256:abc", wantErr: true},
+		{ref: "oci://gcr.io", wantErr: true},
+	}
+	for _, tc := range tests {
+		got, err := parseOCIReference(tc.ref)
+		if (err != nil) != tc.wantErr || got != tc.want {
+			t.Errorf("parseOCIReference(%q) = %+v, %v, want %+v", tc.ref, got, err, tc.want)
+		}
+	}
+}
+
+type roundTripperFunc func(*http.Request) (*http.Response, error)
+
+func (f roundTripperFunc) RoundTrip(r *http.Request) (*http.Response, error) { return f(r) }
@@ -0,0 +1,57 @@
+package loaders
+
+import (
+	"context"
+	"time"
+
+	"sigs.k8s.io/controller-runtime/pkg/log"
+)
+
+// DefaultProgressDeadline is how long a new version has to become healthy before it is rolled back.
+const DefaultProgressDeadline = 10 * time.Minute
+
+// progressDeadline returns spec.upgradePolicy.progressDeadline of object, DefaultProgressDeadline if unset.
+func progressDeadline(object Upgradable) time.Duration {
+	if policy := object.GetUpgradePolicy(); policy != nil && policy.ProgressDeadline != nil {
+		return policy.ProgressDeadline.Duration
+	}
+	return DefaultProgressDeadline
+}
+
+// rollback replaces the requested version of resolution with the last healthy version of object if it
+// missed its progress deadline, or was rolled back before. Otherwise it sets the progress deadline of
+// the requested version, which starts when it is first requested. The requested version is kept if its
+// upgrade path does not allow moving back to the last healthy version, e.g. as it changed the schema of
+// a CRD.
+func (l *ManifestLoader) rollback(ctx context.Context, resolution *Resolution, packageName string, object Upgradable, now time.Time) {
+	status := object.GetRollbackStatus()
+	deadline := progressDeadline(object)
+	if status.LastHealthyVersion == "" || resolution.Version == status.LastHealthyVersion || deadline <= 0 {
+		return
+	}
+
+	requested := resolution.Version
+	if requested != status.RolledBackVersion {
+		if status.ProgressingVersion != requested || status.ProgressDeadline == nil {
+			resolution.ProgressDeadline = now.Add(deadline)
+			return
+		}
+		if now.Before(status.ProgressDeadline.Time) {
+			resolution.ProgressDeadline = status.ProgressDeadline.Time
+			return
+		}
+	}
+	if err := l.CheckUpgradePath(ctx, packageName, requested, status.LastHealthyVersion); err != nil {
+		log.FromContext(ctx).Info("not rolling back version which missed its progress deadline",
+			"version", requested, "lastHealthyVersion", status.LastHealthyVersion, "reason", err.Error())
+		resolution.RollbackBlocked = err.Error()
+		return
+	}
+	if requested != status.RolledBackVersion {
+		log.FromContext(ctx).Info("rolling back version which missed its progress deadline",
+			"version", requested, "progressDeadline", status.ProgressDeadline, "lastHealthyVersion", status.LastHealthyVersion)
+	}
+	resolution.Version = status.LastHealthyVersion
+	resolution.RolledBackVersion = requested
+	resolution.Available = ""
+}
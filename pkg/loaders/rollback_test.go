@@ -0,0 +1,113 @@
+package loaders
+
+import (
+	"context"
+	"path/filepath"
+	"testing"
+	"time"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	addonsv1alpha1 "github.com/yuwenma/moss/moss/api/v1alpha1"
+)
+
+func TestRollback(t *testing.T) {
+	dir := t.TempDir()
+	writeFile(t, filepath.Join(dir, "packages", "argocd", "2.7.0", "metadata", "upgrade.yaml"), "downgradeAllowed: false\n")
+	loader, err := NewManifestLoader(dir, nil, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	now := time.Date(2023, 5, 3, 10, 0, 0, 0, time.UTC)
+	deadline := func(t time.Time) *metav1.Time {
+		return &metav1.Time{Time: t}
+	}
+	tests := []struct {
+		name                  string
+		requested             string
+		status                addonsv1alpha1.RollbackStatus
+		policy                *addonsv1alpha1.UpgradePolicy
+		want                  string
+		wantProgressDeadline  time.Time
+		wantRolledBackVersion string
+		wantRollbackBlocked   bool
+	}{
+		{
+			name:      "install",
+			requested: "2.5.11",
+			want:      "2.5.11",
+		},
+		{
+			name:      "healthy",
+			requested: "2.5.11",
+			status:    addonsv1alpha1.RollbackStatus{LastHealthyVersion: "2.5.11"},
+			want:      "2.5.11",
+		},
+		{
+			name:                 "upgrade requested",
+			requested:            "2.6.0",
+			status:               addonsv1alpha1.RollbackStatus{LastHealthyVersion: "2.5.11"},
+			want:                 "2.6.0",
+			wantProgressDeadline: now.Add(DefaultProgressDeadline),
+		},
+		{
+			name:                 "upgrade progressing",
+			requested:            "2.6.0",
+			status:               addonsv1alpha1.RollbackStatus{LastHealthyVersion: "2.5.11", ProgressingVersion: "2.6.0", ProgressDeadline: deadline(now.Add(time.Minute))},
+			want:                 "2.6.0",
+			wantProgressDeadline: now.Add(time.Minute),
+		},
+		{
+			name:                 "other upgrade requested",
+			requested:            "2.7.0",
+			status:               addonsv1alpha1.RollbackStatus{LastHealthyVersion: "2.5.11", ProgressingVersion: "2.6.0", ProgressDeadline: deadline(now.Add(-time.Minute))},
+			policy:               &addonsv1alpha1.UpgradePolicy{ProgressDeadline: &metav1.Duration{Duration: time.Hour}},
+			want:                 "2.7.0",
+			wantProgressDeadline: now.Add(time.Hour),
+		},
+		{
+			name:                  "deadline exceeded",
+			requested:             "2.6.0",
+			status:                addonsv1alpha1.RollbackStatus{LastHealthyVersion: "2.5.11", ProgressingVersion: "2.6.0", ProgressDeadline: deadline(now.Add(-time.Minute))},
+			want:                  "2.5.11",
+			wantRolledBackVersion: "2.6.0",
+		},
+		{
+			name:                  "rolled back",
+			requested:             "2.6.0",
+			status:                addonsv1alpha1.RollbackStatus{LastHealthyVersion: "2.5.11", RolledBackVersion: "2.6.0"},
+			want:                  "2.5.11",
+			wantRolledBackVersion: "2.6.0",
+		},
+		{
+			name:                "downgrade not allowed",
+			requested:           "2.7.0",
+			status:              addonsv1alpha1.RollbackStatus{LastHealthyVersion: "2.5.11", ProgressingVersion: "2.7.0", ProgressDeadline: deadline(now.Add(-time.Minute))},
+			want:                "2.7.0",
+			wantRollbackBlocked: true,
+		},
+		{
+			name:      "rollbacks disabled",
+			requested: "2.6.0",
+			status:    addonsv1alpha1.RollbackStatus{LastHealthyVersion: "2.5.11", ProgressingVersion: "2.6.0", ProgressDeadline: deadline(now.Add(-time.Minute))},
+			policy:    &addonsv1alpha1.UpgradePolicy{ProgressDeadline: &metav1.Duration{}},
+			want:      "2.6.0",
+		},
+	}
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			argocd := &addonsv1alpha1.ArgoCD{}
+			argocd.Spec.UpgradePolicy = tc.policy
+			argocd.Status.RollbackStatus = tc.status
+			resolution := Resolution{Version: tc.requested}
+			loader.rollback(context.Background(), &resolution, "argocd", argocd, now)
+			if resolution.Version != tc.want || !resolution.ProgressDeadline.Equal(tc.wantProgressDeadline) || resolution.RolledBackVersion != tc.wantRolledBackVersion {
+				t.Errorf("rollback() = version %s, progress deadline %s, rolled back %q, want version %s, progress deadline %s, rolled back %q",
+					resolution.Version, resolution.ProgressDeadline, resolution.RolledBackVersion, tc.want, tc.wantProgressDeadline, tc.wantRolledBackVersion)
+			}
+			if blocked := resolution.RollbackBlocked != ""; blocked != tc.wantRollbackBlocked {
+				t.Errorf("rollback() blocked = %q, want blocked %t", resolution.RollbackBlocked, tc.wantRollbackBlocked)
+			}
+		})
+	}
+}
@@ -0,0 +1,94 @@
+package loaders
+
+import (
+	"context"
+	"crypto/ed25519"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"os"
+	"path"
+	"strings"
+)
+
+// ChannelPublicKeyFile is the PEM encoded ed25519 public key the channels are signed with. Channels
+// are not verified if empty.
+var ChannelPublicKeyFile string
+
+// signatureSuffix is appended to the path of a channel to get the path of its signature, e.g.
+// `stable.sig`. The signature is the base64 encoded ed25519 signature of the channel file.
+const signatureSuffix = ".sig"
+
+// loadPublicKey reads the ed25519 public key of the PEM file name.
+func loadPublicKey(name string) (ed25519.PublicKey, error) {
+	b, err := os.ReadFile(name)
+	if err != nil {
+		return nil, fmt.Errorf("error reading channel public key: %w", err)
+	}
+	block, _ := pem.Decode(b)
+	if block == nil || block.Type != "PUBLIC KEY" {
+		return nil, fmt.Errorf("error reading channel public key %s: no PUBLIC KEY PEM block", name)
+	}
+	key, err := x509.ParsePKIXPublicKey(block.Bytes)
+	if err != nil {
+		return nil, fmt.Errorf("error parsing channel public key %s: %w", name, err)
+	}
+	publicKey, ok := key.(ed25519.PublicKey)
+	if !ok {
+		return nil, fmt.Errorf("unsupported channel public key %s: %T, expected ed25519", name, key)
+	}
+	return publicKey, nil
+}
+
+// verifySignature checks that the channel b is signed by the key of the loader.
+func (l *ManifestLoader) verifySignature(ctx context.Context, name string, b []byte) error {
+	sig, err := l.files(ctx, name+signatureSuffix)
+	if errors.Is(err, os.ErrNotExist) {
+		return fmt.Errorf("channel %q is not signed", name)
+	}
+	if err != nil {
+		return err
+	}
+	signature, err := base64.StdEncoding.DecodeString(strings.TrimSpace(string(sig)))
+	if err != nil {
+		return fmt.Errorf("error decoding signature of channel %q: %w", name, err)
+	}
+	if !ed25519.Verify(l.publicKey, b, signature) {
+		return fmt.Errorf("invalid signature of channel %q", name)
+	}
+	return nil
+}
+
+// loadVerifiedManifest loads the package id of the addon, checking that its manifest has the digest
+// listed for it by the signed channel. Packages which are not listed by the channel are rejected.
+func (l *ManifestLoader) loadVerifiedManifest(ctx context.Context, channelName, packageName, id string) (map[string]string, error) {
+	if !allowedManifestID(packageName) || !allowedManifestID(id) {
+		return nil, fmt.Errorf("invalid package %q or manifest id %q", packageName, id)
+	}
+	channel, err := l.loadChannel(ctx, channelName)
+	if err != nil {
+		return nil, err
+	}
+	var want string
+	for _, v := range channel.Manifests {
+		if v.Package == packageName && v.Digests[id] != "" {
+			want = v.Digests[id]
+			break
+		}
+	}
+	if want == "" {
+		return nil, fmt.Errorf("no digest of %s %s in signed channel %q", packageName, id, channelName)
+	}
+
+	name := path.Join("packages", packageName, id, "manifest.yaml")
+	b, err := l.files(ctx, name)
+	if err != nil {
+		return nil, err
+	}
+	if got := sha256Digest(b); got != want {
+		return nil, fmt.Errorf("digest mismatch of %s %s: channel %q lists %s, got %s", packageName, id, channelName, want, got)
+	}
+	return map[string]string{name: string(b)}, nil
+}
@@ -0,0 +1,118 @@
+package loaders
+
+import (
+	"context"
+	"crypto/ed25519"
+	"crypto/rand"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/pem"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	addonsv1alpha1 "github.com/yuwenma/moss/moss/api/v1alpha1"
+)
+
+func TestSignedChannel(t *testing.T) {
+	publicKey, privateKey, err := ed25519.GenerateKey(rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	der, err := x509.MarshalPKIXPublicKey(publicKey)
+	if err != nil {
+		t.Fatal(err)
+	}
+	keyFile := filepath.Join(t.TempDir(), "channel.pub")
+	writeFile(t, keyFile, string(pem.EncodeToMemory(&pem.Block{Type: "PUBLIC KEY", Bytes: der})))
+	defer func(name string) { ChannelPublicKeyFile = name }(ChannelPublicKeyFile)
+	ChannelPublicKeyFile = keyFile
+
+	manifest := "kind: Namespace\n"
+	channel := "manifests:\n- name: configsync\n  version: 1.14.1\n  digests:\n    1.14.1: " + sha256Digest([]byte(manifest)) + "\n"
+	sign := func(b string) string { return base64.StdEncoding.EncodeToString(ed25519.Sign(privateKey, []byte(b))) }
+
+	tests := []struct {
+		name    string
+		version string
+		files   map[string]string
+		wantErr string
+	}{
+		{
+			name: "signed",
+			files: map[string]string{
+				"stable": channel, "stable.sig": sign(channel),
+				"packages/configsync/1.14.1/manifest.yaml": manifest,
+			},
+		},
+		{
+			name: "not signed",
+			files: map[string]string{
+				"stable": channel,
+				"packages/configsync/1.14.1/manifest.yaml": manifest,
+			},
+			wantErr: "not signed",
+		},
+		{
+			name: "tampered channel",
+			files: map[string]string{
+				"stable": channel + "- name: configsync\n  version: 1.15.0\n", "stable.sig": sign(channel),
+				"packages/configsync/1.14.1/manifest.yaml": manifest,
+			},
+			wantErr: "invalid signature",
+		},
+		{
+			name: "tampered manifest",
+			files: map[string]string{
+				"stable": channel, "stable.sig": sign(channel),
+				"packages/configsync/1.14.1/manifest.yaml": "kind: Secret\n",
+			},
+			wantErr: "digest mismatch",
+		},
+		{
+			name:    "version not in channel",
+			version: "1.13.0",
+			files: map[string]string{
+				"stable": channel, "stable.sig": sign(channel),
+				"packages/configsync/1.13.0/manifest.yaml": manifest,
+			},
+			wantErr: "no digest",
+		},
+	}
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				b, ok := tc.files[strings.TrimPrefix(r.URL.Path, "/channels/")]
+				if !ok {
+					http.NotFound(w, r)
+					return
+				}
+				w.Write([]byte(b))
+			}))
+			defer server.Close()
+
+			loader, err := NewManifestLoader(server.URL+"/channels", nil, nil)
+			if err != nil {
+				t.Fatal(err)
+			}
+			configSync := &addonsv1alpha1.ConfigSync{}
+			configSync.Name = "configsync-sample"
+			configSync.Spec.Version = tc.version
+			got, err := loader.ResolveManifest(context.Background(), configSync)
+			if tc.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tc.wantErr) {
+					t.Errorf("ResolveManifest() error = %v, want %q", err, tc.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("ResolveManifest() error = %v", err)
+			}
+			if len(got) != 1 || got["packages/configsync/1.14.1/manifest.yaml"] != manifest {
+				t.Errorf("ResolveManifest() = %v, want the manifest of 1.14.1", got)
+			}
+		})
+	}
+}
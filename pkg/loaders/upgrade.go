@@ -0,0 +1,92 @@
+package loaders
+
+import (
+	"fmt"
+	"time"
+
+	semver "github.com/blang/semver/v4"
+
+	addonsv1alpha1 "github.com/yuwenma/moss/moss/api/v1alpha1"
+	"github.com/yuwenma/moss/moss/pkg/schedule"
+)
+
+// ChannelPollInterval is how often the channel of an addon is read again for new versions.
+const ChannelPollInterval = 10 * time.Minute
+
+// Upgradable is implemented by the addons with an upgrade policy.
+type Upgradable interface {
+	// GetUpgradePolicy returns spec.upgradePolicy, nil if unset.
+	GetUpgradePolicy() *addonsv1alpha1.UpgradePolicy
+	// GetDeployedPackage returns the package last applied successfully.
+	GetDeployedPackage() addonsv1alpha1.DeployedPackage
+	// GetRollbackStatus returns the health of the applied versions.
+	GetRollbackStatus() addonsv1alpha1.RollbackStatus
+}
+
+var _ Upgradable = &addonsv1alpha1.ArgoCD{}
+var _ Upgradable = &addonsv1alpha1.ConfigSync{}
+
+// upgrade decides between the deployed version and the newer version available in the channel.
+type upgrade struct {
+	// deployed is the version last applied from the same channel, empty on install or after a
+	// change of spec.version or spec.channel.
+	deployed string
+	// available is the newest eligible version of the channel.
+	available string
+	policy    *addonsv1alpha1.UpgradePolicy
+}
+
+// pending reports whether the available version is newer than the deployed one.
+func (u *upgrade) pending() bool {
+	if u.deployed == "" || u.deployed == u.available {
+		return false
+	}
+	deployed, err := semver.ParseTolerant(u.deployed)
+	if err != nil {
+		return false
+	}
+	available, err := semver.ParseTolerant(u.available)
+	if err != nil {
+		return false
+	}
+	return available.GT(deployed)
+}
+
+// version returns the version to apply at now, and when the maintenance window opens next if the
+// upgrade waits for it.
+func (u *upgrade) version(now time.Time) (string, time.Time, error) {
+	if u.deployed == "" {
+		return u.available, time.Time{}, nil
+	}
+	if !u.pending() {
+		// Addons do not follow their channel back to older versions, e.g. when their version was
+		// promoted before spec.upgradePolicy.minSoakTime was set.
+		return u.deployed, time.Time{}, nil
+	}
+	if u.policy != nil && u.policy.Mode == addonsv1alpha1.UpgradeModeManual {
+		return u.deployed, time.Time{}, nil
+	}
+	if u.policy == nil || u.policy.MaintenanceWindow == nil {
+		return u.available, time.Time{}, nil
+	}
+
+	window := u.policy.MaintenanceWindow
+	s, err := schedule.Parse(window.Schedule)
+	if err != nil {
+		return "", time.Time{}, fmt.Errorf("spec.upgradePolicy.maintenanceWindow: %w", err)
+	}
+	now = now.UTC()
+	// The window is open if it started less than its duration ago.
+	if start := s.Next(now.Add(-window.Duration.Duration)); !start.IsZero() && !start.After(now) {
+		return u.available, time.Time{}, nil
+	}
+	return u.deployed, s.Next(now), nil
+}
+
+// minSoakTime returns spec.upgradePolicy.minSoakTime of object, 0 if unset.
+func minSoakTime(object Upgradable) time.Duration {
+	if policy := object.GetUpgradePolicy(); policy != nil && policy.MinSoakTime != nil {
+		return policy.MinSoakTime.Duration
+	}
+	return 0
+}
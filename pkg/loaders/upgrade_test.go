@@ -0,0 +1,149 @@
+package loaders
+
+import (
+	"context"
+	"path/filepath"
+	"testing"
+	"time"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+
+	addonsv1alpha1 "github.com/yuwenma/moss/moss/api/v1alpha1"
+)
+
+func TestUpgradeVersion(t *testing.T) {
+	// Saturdays from 2:00 to 6:00.
+	window := &addonsv1alpha1.MaintenanceWindow{Schedule: "0 2 * * 6", Duration: metav1.Duration{Duration: 4 * time.Hour}}
+	// A Wednesday.
+	wednesday := time.Date(2023, 5, 3, 10, 0, 0, 0, time.UTC)
+	saturday := time.Date(2023, 5, 6, 3, 0, 0, 0, time.UTC)
+	nextWindow := time.Date(2023, 5, 6, 2, 0, 0, 0, time.UTC)
+	tests := []struct {
+		name           string
+		upgrade        upgrade
+		now            time.Time
+		want           string
+		wantNextWindow time.Time
+	}{
+		{
+			name:    "install",
+			upgrade: upgrade{available: "1.15.0", policy: &addonsv1alpha1.UpgradePolicy{Mode: addonsv1alpha1.UpgradeModeManual}},
+			now:     wednesday,
+			want:    "1.15.0",
+		},
+		{
+			name:    "no policy",
+			upgrade: upgrade{deployed: "1.14.1", available: "1.15.0"},
+			now:     wednesday,
+			want:    "1.15.0",
+		},
+		{
+			name:    "manual",
+			upgrade: upgrade{deployed: "1.14.1", available: "1.15.0", policy: &addonsv1alpha1.UpgradePolicy{Mode: addonsv1alpha1.UpgradeModeManual, MaintenanceWindow: window}},
+			now:     saturday,
+			want:    "1.14.1",
+		},
+		{
+			name:           "window closed",
+			upgrade:        upgrade{deployed: "1.14.1", available: "1.15.0", policy: &addonsv1alpha1.UpgradePolicy{MaintenanceWindow: window}},
+			now:            wednesday,
+			want:           "1.14.1",
+			wantNextWindow: nextWindow,
+		},
+		{
+			name:    "window open",
+			upgrade: upgrade{deployed: "1.14.1", available: "1.15.0", policy: &addonsv1alpha1.UpgradePolicy{MaintenanceWindow: window}},
+			now:     saturday,
+			want:    "1.15.0",
+		},
+		{
+			name:           "window ended",
+			upgrade:        upgrade{deployed: "1.14.1", available: "1.15.0", policy: &addonsv1alpha1.UpgradePolicy{MaintenanceWindow: window}},
+			now:            time.Date(2023, 5, 6, 6, 0, 0, 0, time.UTC),
+			want:           "1.14.1",
+			wantNextWindow: time.Date(2023, 5, 13, 2, 0, 0, 0, time.UTC),
+		},
+		{
+			name:    "older version available",
+			upgrade: upgrade{deployed: "1.15.0", available: "1.14.1"},
+			now:     wednesday,
+			want:    "1.15.0",
+		},
+	}
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			got, gotNextWindow, err := tc.upgrade.version(tc.now)
+			if err != nil {
+				t.Fatalf("version() error = %v", err)
+			}
+			if got != tc.want || !gotNextWindow.Equal(tc.wantNextWindow) {
+				t.Errorf("version() = %s, %s, want %s, %s", got, gotNextWindow, tc.want, tc.wantNextWindow)
+			}
+		})
+	}
+}
+
+func TestManifestLoaderUpgradePolicy(t *testing.T) {
+	now := time.Now().UTC()
+	dir := t.TempDir()
+	writeFile(t, filepath.Join(dir, "stable"), `manifests:
+- name: configsync
+  version: 1.14.1
+  promoted: "2023-02-01"
+- name: configsync
+  version: 1.15.0
+  promoted: "`+now.AddDate(0, 0, -2).Format(promotionDateLayout)+`"
+`)
+	writeFile(t, filepath.Join(dir, "packages", "configsync", "1.14.1", "manifest.yaml"), "kind: Namespace\n")
+	writeFile(t, filepath.Join(dir, "packages", "configsync", "1.15.0", "manifest.yaml"), "kind: Namespace\n")
+
+	loader, err := NewManifestLoader(dir, nil, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	ctx := context.Background()
+	configSync := &addonsv1alpha1.ConfigSync{}
+	configSync.Name = "configsync-sample"
+	configSync.Status.DeployedPackage = addonsv1alpha1.DeployedPackage{ObservedVersion: "1.14.1", ResolvedChannel: DefaultChannel}
+	// A daily window which opened an hour ago.
+	window := &addonsv1alpha1.MaintenanceWindow{
+		Schedule: now.Add(-time.Hour).Format("4 15 * * *"),
+		Duration: metav1.Duration{Duration: 2 * time.Hour},
+	}
+
+	tests := []struct {
+		name          string
+		policy        *addonsv1alpha1.UpgradePolicy
+		want          string
+		wantAvailable string
+	}{
+		{name: "no policy", want: "1.15.0"},
+		{name: "soaking", policy: &addonsv1alpha1.UpgradePolicy{MinSoakTime: &metav1.Duration{Duration: 7 * 24 * time.Hour}}, want: "1.14.1"},
+		{name: "manual", policy: &addonsv1alpha1.UpgradePolicy{Mode: addonsv1alpha1.UpgradeModeManual}, want: "1.14.1", wantAvailable: "1.15.0"},
+		{name: "soaked in window", policy: &addonsv1alpha1.UpgradePolicy{MinSoakTime: &metav1.Duration{Duration: 24 * time.Hour}, MaintenanceWindow: window}, want: "1.15.0"},
+	}
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			configSync.Spec.UpgradePolicy = tc.policy
+			if _, err := loader.ResolveManifest(ctx, configSync); err != nil {
+				t.Fatalf("ResolveManifest() error = %v", err)
+			}
+			got, _ := loader.Resolved(configSync)
+			if got.Version != tc.want || got.Available != tc.wantAvailable {
+				t.Errorf("Resolved() = version %s, available %s, want version %s, available %s", got.Version, got.Available, tc.want, tc.wantAvailable)
+			}
+			if requeue := loader.RequeueAfter(types.NamespacedName{Name: configSync.Name}, now); requeue <= 0 || requeue > ChannelPollInterval {
+				t.Errorf("RequeueAfter() = %s, want at most %s", requeue, ChannelPollInterval)
+			}
+		})
+	}
+
+	configSync.Spec.Version = "1.14.1"
+	if _, err := loader.ResolveManifest(ctx, configSync); err != nil {
+		t.Fatalf("ResolveManifest() error = %v", err)
+	}
+	if requeue := loader.RequeueAfter(types.NamespacedName{Name: configSync.Name}, now); requeue != 0 {
+		t.Errorf("RequeueAfter() = %s with spec.version set, want 0", requeue)
+	}
+}
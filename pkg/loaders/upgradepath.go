@@ -0,0 +1,108 @@
+package loaders
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"path"
+	"strings"
+
+	semver "github.com/blang/semver/v4"
+	"sigs.k8s.io/yaml"
+)
+
+// upgradePathFile is the path of the UpgradePath of a package version, relative to its directory.
+// It is in a subdirectory so that it is not loaded as part of the manifest.
+const upgradePathFile = "metadata/upgrade.yaml"
+
+// UpgradePath restricts the versions a package version can be upgraded from and downgraded to. It is
+// stored in `packages/<addon>/<version>/metadata/upgrade.yaml`.
+type UpgradePath struct {
+	// From are the semver ranges of the versions which can be upgraded to this version, e.g.
+	// ">=1.12.0 <1.14.1". Any older version can be upgraded if empty.
+	From []string `json:"from,omitempty"`
+	// DowngradeAllowed tells whether this version can be downgraded to an older version, e.g. false if
+	// it changed the schema of a CRD. Defaults to true.
+	DowngradeAllowed *bool `json:"downgradeAllowed,omitempty"`
+}
+
+// CheckUpgradePath returns an error explaining why the package cannot move from version from to
+// version to, or nil if the upgrade paths of the package versions allow it. Versions without
+// upgrade path, and repositories which cannot store them, allow any change.
+func (l *ManifestLoader) CheckUpgradePath(ctx context.Context, packageName, from, to string) error {
+	if from == "" || to == "" || from == to {
+		return nil
+	}
+	fromVersion, err := semver.ParseTolerant(from)
+	if err != nil {
+		return fmt.Errorf("invalid version %q: %w", from, err)
+	}
+	toVersion, err := semver.ParseTolerant(to)
+	if err != nil {
+		return fmt.Errorf("invalid version %q: %w", to, err)
+	}
+
+	if toVersion.LT(fromVersion) {
+		p, err := l.loadUpgradePath(ctx, packageName, from)
+		if err != nil || p == nil {
+			return err
+		}
+		if p.DowngradeAllowed != nil && !*p.DowngradeAllowed {
+			return fmt.Errorf("%s %s cannot be downgraded, to return to %s uninstall it first", packageName, from, to)
+		}
+		return nil
+	}
+
+	p, err := l.loadUpgradePath(ctx, packageName, to)
+	if err != nil || p == nil || len(p.From) == 0 {
+		return err
+	}
+	for _, r := range p.From {
+		allowed, err := semver.ParseRange(r)
+		if err != nil {
+			return fmt.Errorf("invalid upgrade path of %s %s: %q: %w", packageName, to, r, err)
+		}
+		if allowed(fromVersion) {
+			return nil
+		}
+	}
+	return fmt.Errorf("%s %s can only be upgraded to from versions %s, upgrade %s to an intermediate version first",
+		packageName, to, strings.Join(p.From, " or "), from)
+}
+
+// loadUpgradePath loads the UpgradePath of a package version, nil if it has none.
+func (l *ManifestLoader) loadUpgradePath(ctx context.Context, packageName, version string) (*UpgradePath, error) {
+	if l.files == nil {
+		return nil, nil
+	}
+	if !allowedManifestID(packageName) || !allowedManifestID(version) {
+		return nil, fmt.Errorf("invalid package %q or version %q", packageName, version)
+	}
+	b, err := l.files(ctx, path.Join("packages", packageName, version, upgradePathFile))
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	p := &UpgradePath{}
+	if err := yaml.Unmarshal(b, p); err != nil {
+		return nil, fmt.Errorf("error parsing upgrade path of %s %s: %w", packageName, version, err)
+	}
+	return p, nil
+}
+
+// allowedManifestID keeps package names and versions to the characters kubebuilder-declarative-pattern
+// accepts, so that they are safe to use as paths.
+func allowedManifestID(id string) bool {
+	if id == "" || strings.HasPrefix(id, ".") {
+		return false
+	}
+	for _, r := range id {
+		if !(r >= 'a' && r <= 'z' || r >= '0' && r <= '9' || r == '-' || r == '.') {
+			return false
+		}
+	}
+	return true
+}
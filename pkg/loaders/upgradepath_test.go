@@ -0,0 +1,39 @@
+package loaders
+
+import (
+	"context"
+	"path/filepath"
+	"testing"
+)
+
+func TestCheckUpgradePath(t *testing.T) {
+	dir := t.TempDir()
+	writeFile(t, filepath.Join(dir, "packages", "configsync", "1.16.0", "metadata", "upgrade.yaml"), "from:\n- \">=1.14.0 <1.16.0\"\n")
+	writeFile(t, filepath.Join(dir, "packages", "configsync", "1.17.0", "metadata", "upgrade.yaml"), "from:\n- \">=1.15.0 <1.17.0\"\ndowngradeAllowed: false\n")
+
+	loader, err := NewManifestLoader(dir, nil, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	tests := []struct {
+		from, to string
+		wantErr  bool
+	}{
+		{from: "1.14.1", to: "1.14.1"},
+		{from: "1.14.1", to: "1.16.0"},
+		{from: "1.13.0", to: "1.16.0", wantErr: true},
+		{from: "1.14.1", to: "1.17.0", wantErr: true},
+		{from: "1.16.0", to: "1.17.0"},
+		// Without upgrade path.
+		{from: "1.10.0", to: "1.15.0"},
+		{from: "1.16.0", to: "1.14.1"},
+		{from: "1.17.0", to: "1.16.0", wantErr: true},
+		{from: "1.14.1", to: "../1.16.0", wantErr: true},
+	}
+	for _, tc := range tests {
+		err := loader.CheckUpgradePath(context.Background(), "configsync", tc.from, tc.to)
+		if (err != nil) != tc.wantErr {
+			t.Errorf("CheckUpgradePath(%s, %s) error = %v, want error %t", tc.from, tc.to, err, tc.wantErr)
+		}
+	}
+}
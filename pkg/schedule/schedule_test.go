@@ -0,0 +1,45 @@
+package schedule
+
+import (
+	"testing"
+	"time"
+)
+
+func TestNext(t *testing.T) {
+	// A Wednesday.
+	from := time.Date(2023, 5, 3, 10, 30, 0, 0, time.UTC)
+	tests := []struct {
+		spec string
+		want time.Time
+	}{
+		{spec: "* * * * *", want: time.Date(2023, 5, 3, 10, 31, 0, 0, time.UTC)},
+		{spec: "0 2 * * *", want: time.Date(2023, 5, 4, 2, 0, 0, 0, time.UTC)},
+		{spec: "0 2 * * 6", want: time.Date(2023, 5, 6, 2, 0, 0, 0, time.UTC)},
+		{spec: "0 2 * * 7", want: time.Date(2023, 5, 7, 2, 0, 0, 0, time.UTC)},
+		{spec: "*/20 10-11 * * *", want: time.Date(2023, 5, 3, 10, 40, 0, 0, time.UTC)},
+		{spec: "15 10 1 * *", want: time.Date(2023, 6, 1, 10, 15, 0, 0, time.UTC)},
+		{spec: "0 0 1,15 3 *", want: time.Date(2024, 3, 1, 0, 0, 0, 0, time.UTC)},
+		// Both day fields restricted: the 10th or any Monday.
+		{spec: "0 0 10 * 1", want: time.Date(2023, 5, 8, 0, 0, 0, 0, time.UTC)},
+		{spec: "0 0 30 2 *"},
+	}
+	for _, tc := range tests {
+		t.Run(tc.spec, func(t *testing.T) {
+			s, err := Parse(tc.spec)
+			if err != nil {
+				t.Fatalf("Parse() error = %v", err)
+			}
+			if got := s.Next(from); !got.Equal(tc.want) {
+				t.Errorf("Next(%s) = %s, want %s", from, got, tc.want)
+			}
+		})
+	}
+}
+
+func TestParseErrors(t *testing.T) {
+	for _, spec := range []string{"", "* * * *", "60 * * * *", "* 24 * * *", "* * 0 * *", "5-1 * * * *", "*/0 * * * *", "a * * * *", "* * * * 8"} {
+		if _, err := Parse(spec); err == nil {
+			t.Errorf("Parse(%q) succeeded, want an error", spec)
+		}
+	}
+}
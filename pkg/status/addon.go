@@ -0,0 +1,80 @@
+package status
+
+import (
+	"context"
+	"fmt"
+	"reflect"
+
+	"k8s.io/apimachinery/pkg/runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/kubebuilder-declarative-pattern/pkg/patterns/addon/pkg/utils"
+	"sigs.k8s.io/kubebuilder-declarative-pattern/pkg/patterns/declarative"
+)
+
+// BuildFunc sets the addon specific fields of the status of info.Subject.
+type BuildFunc func(ctx context.Context, info *declarative.StatusInfo) error
+
+// WithAddonStatus wraps s so that fn sets the addon specific status fields after s computed the common ones.
+// k-d-p only writes the status when the common status changes, so the status is written with c when only
+// the fields set by fn changed.
+func WithAddonStatus(c client.Client, s declarative.Status, fn BuildFunc) declarative.Status {
+	return &declarative.StatusBuilder{
+		ReconciledImpl:   s,
+		PreflightImpl:    s,
+		VersionCheckImpl: s,
+		BuildStatusImpl:  &addonStatus{client: c, inner: s, fn: fn},
+	}
+}
+
+type addonStatus struct {
+	client client.Client
+	inner  declarative.BuildStatus
+	fn     BuildFunc
+}
+
+func (a *addonStatus) BuildStatus(ctx context.Context, info *declarative.StatusInfo) error {
+	original := info.Subject.DeepCopyObject()
+
+	if err := a.inner.BuildStatus(ctx, info); err != nil {
+		return err
+	}
+	if err := a.fn(ctx, info); err != nil {
+		return err
+	}
+
+	oldCommon, err := utils.GetCommonStatus(original)
+	if err != nil {
+		return err
+	}
+	newCommon, err := utils.GetCommonStatus(info.Subject)
+	if err != nil {
+		return err
+	}
+	if !reflect.DeepEqual(oldCommon, newCommon) {
+		// Written by the reconciler.
+		return nil
+	}
+	oldStatus, err := statusOf(original)
+	if err != nil {
+		return err
+	}
+	newStatus, err := statusOf(info.Subject)
+	if err != nil {
+		return err
+	}
+	if reflect.DeepEqual(oldStatus, newStatus) {
+		return nil
+	}
+	if err := a.client.Status().Update(ctx, info.Subject); err != nil {
+		return fmt.Errorf("error updating status: %w", err)
+	}
+	return nil
+}
+
+func statusOf(o runtime.Object) (interface{}, error) {
+	u, err := runtime.DefaultUnstructuredConverter.ToUnstructured(o)
+	if err != nil {
+		return nil, fmt.Errorf("error converting %T: %w", o, err)
+	}
+	return u["status"], nil
+}
@@ -0,0 +1,46 @@
+package status
+
+import (
+	"context"
+	"fmt"
+
+	kstatus "sigs.k8s.io/cli-utils/pkg/kstatus/status"
+	"sigs.k8s.io/kubebuilder-declarative-pattern/pkg/patterns/declarative"
+
+	addonsv1alpha1 "github.com/yuwenma/moss/moss/api/v1alpha1"
+)
+
+// BlockingObject returns the status of the first applied object which has not reached its desired state
+// according to kstatus. It returns nil if all the objects reached it, or if the manifest was not applied.
+func BlockingObject(ctx context.Context, info *declarative.StatusInfo) (*addonsv1alpha1.ObjectStatus, error) {
+	if info.Manifest == nil || info.LiveObjects == nil {
+		return nil, nil
+	}
+	for _, object := range info.Manifest.Items {
+		gvk := object.GroupVersionKind()
+		key := object.NamespacedName()
+		blocking := &addonsv1alpha1.ObjectStatus{
+			Group:     gvk.Group,
+			Kind:      gvk.Kind,
+			Namespace: key.Namespace,
+			Name:      key.Name,
+		}
+
+		u, err := info.LiveObjects(ctx, gvk, key)
+		if err != nil {
+			blocking.Status = kstatus.UnknownStatus.String()
+			blocking.Message = fmt.Sprintf("error reading object: %v", err)
+			return blocking, nil
+		}
+		result, err := kstatus.Compute(u)
+		if err != nil {
+			return nil, fmt.Errorf("error computing status of %s %s: %w", gvk.Kind, objectName(key), err)
+		}
+		if result.Status != kstatus.CurrentStatus {
+			blocking.Status = result.Status.String()
+			blocking.Message = result.Message
+			return blocking, nil
+		}
+	}
+	return nil, nil
+}
@@ -0,0 +1,72 @@
+package status
+
+import (
+	"context"
+	"fmt"
+
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	kstatus "sigs.k8s.io/cli-utils/pkg/kstatus/status"
+	"sigs.k8s.io/kubebuilder-declarative-pattern/pkg/patterns/declarative"
+
+	addonsv1alpha1 "github.com/yuwenma/moss/moss/api/v1alpha1"
+)
+
+// Components returns the status of the Deployments and StatefulSets of the manifest, in the order of the
+// manifest. It returns false if the manifest was not applied, the live objects are then unknown.
+func Components(ctx context.Context, info *declarative.StatusInfo) ([]addonsv1alpha1.ComponentStatus, bool, error) {
+	if info.Manifest == nil || info.LiveObjects == nil {
+		return nil, false, nil
+	}
+	var components []addonsv1alpha1.ComponentStatus
+	for _, object := range info.Manifest.Items {
+		gvk := object.GroupVersionKind()
+		if gvk.Group != "apps" || (gvk.Kind != "Deployment" && gvk.Kind != "StatefulSet") {
+			continue
+		}
+		key := object.NamespacedName()
+		component := addonsv1alpha1.ComponentStatus{Kind: gvk.Kind, Name: key.Name}
+
+		u, err := info.LiveObjects(ctx, gvk, key)
+		if err != nil {
+			component.Status = kstatus.UnknownStatus.String()
+			component.Message = fmt.Sprintf("error reading object: %v", err)
+			components = append(components, component)
+			continue
+		}
+		result, err := kstatus.Compute(u)
+		if err != nil {
+			return nil, false, fmt.Errorf("error computing status of %s %s: %w", gvk.Kind, objectName(key), err)
+		}
+		component.Status = result.Status.String()
+		if result.Status != kstatus.CurrentStatus {
+			component.Message = result.Message
+		}
+		component.DesiredReplicas = 1
+		if replicas, found, _ := unstructured.NestedInt64(u.Object, "spec", "replicas"); found {
+			component.DesiredReplicas = int32(replicas)
+		}
+		readyReplicas, _, _ := unstructured.NestedInt64(u.Object, "status", "readyReplicas")
+		component.ReadyReplicas = int32(readyReplicas)
+		component.Image = componentImage(u, key.Name)
+		components = append(components, component)
+	}
+	return components, true, nil
+}
+
+// componentImage returns the image of the container named after the workload, so that sidecars such as
+// the plugins of argocd-repo-server are not reported, or of the first container if none is.
+func componentImage(u *unstructured.Unstructured, name string) string {
+	containers, _, _ := unstructured.NestedSlice(u.Object, "spec", "template", "spec", "containers")
+	first := ""
+	for i, c := range containers {
+		container, _ := c.(map[string]interface{})
+		image, _, _ := unstructured.NestedString(container, "image")
+		if containerName, _, _ := unstructured.NestedString(container, "name"); containerName == name {
+			return image
+		}
+		if i == 0 {
+			first = image
+		}
+	}
+	return first
+}
@@ -0,0 +1,130 @@
+package status
+
+import (
+	"context"
+	"fmt"
+	"reflect"
+	"testing"
+
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/kubebuilder-declarative-pattern/pkg/patterns/declarative"
+	"sigs.k8s.io/kubebuilder-declarative-pattern/pkg/patterns/declarative/pkg/manifest"
+
+	addonsv1alpha1 "github.com/yuwenma/moss/moss/api/v1alpha1"
+)
+
+const componentsManifest = `
+apiVersion: v1
+kind: Service
+metadata:
+  name: argocd-server
+  namespace: argocd
+---
+apiVersion: apps/v1
+kind: Deployment
+metadata:
+  name: argocd-server
+  namespace: argocd
+spec:
+  replicas: 2
+---
+apiVersion: apps/v1
+kind: StatefulSet
+metadata:
+  name: argocd-application-controller
+  namespace: argocd
+`
+
+const componentsLive = `
+apiVersion: apps/v1
+kind: Deployment
+metadata:
+  name: argocd-server
+  namespace: argocd
+  generation: 1
+spec:
+  replicas: 2
+  template:
+    spec:
+      containers:
+      - name: oauth-proxy
+        image: quay.io/oauth2-proxy/oauth2-proxy:v7.4.0
+      - name: argocd-server
+        image: quay.io/argoproj/argocd:v2.5.11
+status:
+  observedGeneration: 1
+  replicas: 2
+  readyReplicas: 2
+  availableReplicas: 2
+  updatedReplicas: 2
+  conditions:
+  - type: Available
+    status: "True"
+  - type: Progressing
+    status: "True"
+    reason: NewReplicaSetAvailable
+---
+apiVersion: apps/v1
+kind: StatefulSet
+metadata:
+  name: argocd-application-controller
+  namespace: argocd
+  generation: 1
+spec:
+  replicas: 1
+  template:
+    spec:
+      containers:
+      - name: argocd-application-controller
+        image: quay.io/argoproj/argocd:v2.5.11
+status:
+  observedGeneration: 1
+  replicas: 1
+  currentReplicas: 1
+  updatedReplicas: 1
+  readyReplicas: 0
+`
+
+func TestComponents(t *testing.T) {
+	ctx := context.Background()
+	objects, err := manifest.ParseObjects(ctx, componentsManifest)
+	if err != nil {
+		t.Fatal(err)
+	}
+	live, err := manifest.ParseObjects(ctx, componentsLive)
+	if err != nil {
+		t.Fatal(err)
+	}
+	info := &declarative.StatusInfo{
+		Manifest: objects,
+		LiveObjects: func(ctx context.Context, gvk schema.GroupVersionKind, nn types.NamespacedName) (*unstructured.Unstructured, error) {
+			for _, o := range live.Items {
+				if o.GroupVersionKind() == gvk && o.NamespacedName() == nn {
+					return o.UnstructuredObject(), nil
+				}
+			}
+			return nil, fmt.Errorf("%s %s not found", gvk.Kind, nn)
+		},
+	}
+
+	got, applied, err := Components(ctx, info)
+	if err != nil {
+		t.Fatalf("Components() error = %v", err)
+	}
+	if !applied {
+		t.Fatalf("Components() applied = false, want true")
+	}
+	want := []addonsv1alpha1.ComponentStatus{
+		{Kind: "Deployment", Name: "argocd-server", DesiredReplicas: 2, ReadyReplicas: 2, Image: "quay.io/argoproj/argocd:v2.5.11", Status: "Current"},
+		{Kind: "StatefulSet", Name: "argocd-application-controller", DesiredReplicas: 1, ReadyReplicas: 0, Image: "quay.io/argoproj/argocd:v2.5.11", Status: "InProgress", Message: "Ready: 0/1"},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("Components() = %+v, want %+v", got, want)
+	}
+
+	if _, applied, _ := Components(ctx, &declarative.StatusInfo{Manifest: objects}); applied {
+		t.Errorf("Components() applied = true without live objects, want false")
+	}
+}
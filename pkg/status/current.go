@@ -0,0 +1,61 @@
+package status
+
+import (
+	"context"
+	"fmt"
+
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/meta"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/types"
+	kstatus "sigs.k8s.io/cli-utils/pkg/kstatus/status"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+// CustomResourceDefinitionGVK is the kind of the CRDs an addon waits for, e.g. with IsCurrent.
+var CustomResourceDefinitionGVK = schema.GroupVersionKind{Group: "apiextensions.k8s.io", Version: "v1", Kind: "CustomResourceDefinition"}
+
+// IsCurrent reports whether the object exists and has reached its desired state according to kstatus.
+// If it has not, the returned reason tells which state it is in.
+func IsCurrent(ctx context.Context, c client.Reader, gvk schema.GroupVersionKind, key types.NamespacedName) (bool, string, error) {
+	u := &unstructured.Unstructured{}
+	u.SetGroupVersionKind(gvk)
+	if err := c.Get(ctx, key, u); err != nil {
+		if apierrors.IsNotFound(err) || meta.IsNoMatchError(err) {
+			return false, fmt.Sprintf("%s %s not found", gvk.Kind, objectName(key)), nil
+		}
+		return false, "", fmt.Errorf("error reading %s %s: %w", gvk.Kind, objectName(key), err)
+	}
+	result, err := kstatus.Compute(u)
+	if err != nil {
+		return false, "", fmt.Errorf("error computing status of %s %s: %w", gvk.Kind, objectName(key), err)
+	}
+	if result.Status != kstatus.CurrentStatus {
+		return false, fmt.Sprintf("%s %s is %s", gvk.Kind, objectName(key), result.Status), nil
+	}
+	return true, "", nil
+}
+
+// Exists reports whether the object exists. Objects of a kind that is not served, e.g. because its CRD is
+// not installed yet, do not exist.
+func Exists(ctx context.Context, c client.Reader, gvk schema.GroupVersionKind, key types.NamespacedName) (bool, error) {
+	u := &unstructured.Unstructured{}
+	u.SetGroupVersionKind(gvk)
+	err := c.Get(ctx, key, u)
+	switch {
+	case err == nil:
+		return true, nil
+	case apierrors.IsNotFound(err) || meta.IsNoMatchError(err):
+		return false, nil
+	default:
+		return false, fmt.Errorf("error reading %s %s: %w", gvk.Kind, objectName(key), err)
+	}
+}
+
+func objectName(key types.NamespacedName) string {
+	if key.Namespace == "" {
+		return key.Name
+	}
+	return key.String()
+}
@@ -0,0 +1,50 @@
+package status
+
+import (
+	"context"
+
+	"sigs.k8s.io/kubebuilder-declarative-pattern/pkg/patterns/addon/pkg/utils"
+	"sigs.k8s.io/kubebuilder-declarative-pattern/pkg/patterns/declarative"
+)
+
+// WithReconcileErrors wraps s so that the error of a failed reconcile, e.g. settings rejected by an
+// object transform, is reported in `status.errors` next to the health computed by s.
+func WithReconcileErrors(s declarative.Status) declarative.Status {
+	return &declarative.StatusBuilder{
+		ReconciledImpl:   s,
+		PreflightImpl:    s,
+		VersionCheckImpl: s,
+		BuildStatusImpl:  &reconcileErrors{inner: s},
+	}
+}
+
+type reconcileErrors struct {
+	inner declarative.BuildStatus
+}
+
+func (r *reconcileErrors) BuildStatus(ctx context.Context, info *declarative.StatusInfo) error {
+	// Clear the errors of the previous reconcile, the inner status only sets the ones it computes.
+	currentStatus, err := utils.GetCommonStatus(info.Subject)
+	if err != nil {
+		return err
+	}
+	currentStatus.Errors = nil
+	if err := utils.SetCommonStatus(info.Subject, currentStatus); err != nil {
+		return err
+	}
+
+	if err := r.inner.BuildStatus(ctx, info); err != nil {
+		return err
+	}
+	if info.Err == nil {
+		return nil
+	}
+
+	currentStatus, err = utils.GetCommonStatus(info.Subject)
+	if err != nil {
+		return err
+	}
+	currentStatus.Healthy = false
+	currentStatus.Errors = append(currentStatus.Errors, info.Err.Error())
+	return utils.SetCommonStatus(info.Subject, currentStatus)
+}
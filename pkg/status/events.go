@@ -0,0 +1,134 @@
+package status
+
+import (
+	"context"
+	"fmt"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/client-go/tools/record"
+	"sigs.k8s.io/kubebuilder-declarative-pattern/pkg/patterns/declarative"
+)
+
+// Reasons of the Events recorded on the addon objects.
+const (
+	ReasonInstallStarted = "InstallStarted"
+	ReasonInstalled      = "Installed"
+	ReasonVersionChanged = "VersionChanged"
+	ReasonApplyFailed    = "ApplyFailed"
+	ReasonPruned         = "Pruned"
+	ReasonHealthy        = "Healthy"
+	ReasonUnhealthy      = "Unhealthy"
+	ReasonRolledBack     = "RolledBack"
+)
+
+// WithEvents wraps s so that the milestones and failures of each reconcile are recorded as Events on the
+// addon object. They are derived from the status before and after s built it.
+func WithEvents(recorder record.EventRecorder, s declarative.Status) declarative.Status {
+	return &declarative.StatusBuilder{
+		ReconciledImpl:   s,
+		PreflightImpl:    s,
+		VersionCheckImpl: s,
+		BuildStatusImpl:  &eventsStatus{recorder: recorder, inner: s},
+	}
+}
+
+type eventsStatus struct {
+	recorder record.EventRecorder
+	inner    declarative.BuildStatus
+}
+
+func (e *eventsStatus) BuildStatus(ctx context.Context, info *declarative.StatusInfo) error {
+	oldStatus, err := statusOf(info.Subject)
+	if err != nil {
+		return err
+	}
+	buildErr := e.inner.BuildStatus(ctx, info)
+	newStatus, err := statusOf(info.Subject)
+	if err != nil {
+		return err
+	}
+	old, _ := oldStatus.(map[string]interface{})
+	current, _ := newStatus.(map[string]interface{})
+	subject := info.Subject
+
+	// The phase is set by the first reconcile which gets far enough to build the status, a reconcile failing
+	// before, e.g. while loading the manifest, is retried without it.
+	oldPhase, _, _ := unstructured.NestedString(old, "phase")
+	newPhase, _, _ := unstructured.NestedString(current, "phase")
+	if oldPhase == "" && newPhase != "" {
+		e.recorder.Event(subject, corev1.EventTypeNormal, ReasonInstallStarted, "Installing the addon")
+	}
+	// The reconciler records an InternalError Event for every error, which is only qualified for apply
+	// failures.
+	if info.Err != nil && info.KnownError == declarative.KnownErrorApplyFailed {
+		e.recorder.Event(subject, corev1.EventTypeWarning, ReasonApplyFailed, info.Err.Error())
+	}
+
+	oldVersion, _, _ := unstructured.NestedString(old, "observedVersion")
+	newVersion, _, _ := unstructured.NestedString(current, "observedVersion")
+	switch {
+	case newVersion == oldVersion:
+	case oldVersion == "":
+		e.recorder.Eventf(subject, corev1.EventTypeNormal, ReasonInstalled, "Installed version %s", newVersion)
+	default:
+		e.recorder.Eventf(subject, corev1.EventTypeNormal, ReasonVersionChanged, "Changed version from %s to %s", oldVersion, newVersion)
+	}
+
+	oldRolledBack, _, _ := unstructured.NestedString(old, "rolledBackVersion")
+	newRolledBack, _, _ := unstructured.NestedString(current, "rolledBackVersion")
+	if newRolledBack != "" && newRolledBack != oldRolledBack {
+		e.recorder.Eventf(subject, corev1.EventTypeWarning, ReasonRolledBack, "Version %s did not become healthy before its progress deadline, rolled back to %s", newRolledBack, newVersion)
+	}
+
+	if pruned := prunedCount(old, info); pruned != 0 {
+		e.recorder.Eventf(subject, corev1.EventTypeNormal, ReasonPruned, "Deleted %d objects no longer in the manifest", pruned)
+	}
+
+	oldHealthy, _, _ := unstructured.NestedBool(old, "healthy")
+	newHealthy, _, _ := unstructured.NestedBool(current, "healthy")
+	switch {
+	case newHealthy && !oldHealthy:
+		e.recorder.Event(subject, corev1.EventTypeNormal, ReasonHealthy, "All the objects reached their desired state")
+	case !newHealthy && oldHealthy:
+		phase, _, _ := unstructured.NestedString(current, "phase")
+		e.recorder.Eventf(subject, corev1.EventTypeWarning, ReasonUnhealthy, "The addon is %s", phase)
+	}
+	return buildErr
+}
+
+// prunedCount returns the number of objects of the previous inventory which are not in the applied
+// manifest, and were therefore pruned. Objects beyond the truncated inventory are not counted.
+func prunedCount(oldStatus map[string]interface{}, info *declarative.StatusInfo) int {
+	if info.Err != nil || info.Manifest == nil || info.LiveObjects == nil {
+		return 0
+	}
+	objects, _, _ := unstructured.NestedSlice(oldStatus, "inventory", "objects")
+	if len(objects) == 0 {
+		return 0
+	}
+	applied := map[string]bool{}
+	for _, object := range info.Manifest.Items {
+		gvk := object.GroupVersionKind()
+		applied[inventoryKey(gvk.Group, gvk.Kind, object.GetNamespace(), object.GetName())] = true
+	}
+	pruned := 0
+	for _, o := range objects {
+		m, ok := o.(map[string]interface{})
+		if !ok {
+			continue
+		}
+		group, _, _ := unstructured.NestedString(m, "group")
+		kind, _, _ := unstructured.NestedString(m, "kind")
+		namespace, _, _ := unstructured.NestedString(m, "namespace")
+		name, _, _ := unstructured.NestedString(m, "name")
+		if !applied[inventoryKey(group, kind, namespace, name)] {
+			pruned++
+		}
+	}
+	return pruned
+}
+
+func inventoryKey(group, kind, namespace, name string) string {
+	return fmt.Sprintf("%s/%s/%s/%s", group, kind, namespace, name)
+}
@@ -0,0 +1,168 @@
+package status
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"testing"
+
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/tools/record"
+	"sigs.k8s.io/kubebuilder-declarative-pattern/pkg/patterns/declarative"
+	"sigs.k8s.io/kubebuilder-declarative-pattern/pkg/patterns/declarative/pkg/manifest"
+
+	addonsv1alpha1 "github.com/yuwenma/moss/moss/api/v1alpha1"
+)
+
+// fakeBuildStatus sets the status of the subject to status.
+type fakeBuildStatus struct {
+	status addonsv1alpha1.ConfigSyncStatus
+}
+
+func (f *fakeBuildStatus) Reconciled(context.Context, declarative.DeclarativeObject, *manifest.Objects, error) error {
+	return nil
+}
+
+func (f *fakeBuildStatus) Preflight(context.Context, declarative.DeclarativeObject) error {
+	return nil
+}
+
+func (f *fakeBuildStatus) VersionCheck(context.Context, declarative.DeclarativeObject, *manifest.Objects) (bool, error) {
+	return true, nil
+}
+
+func (f *fakeBuildStatus) BuildStatus(ctx context.Context, info *declarative.StatusInfo) error {
+	info.Subject.(*addonsv1alpha1.ConfigSync).Status = f.status
+	return nil
+}
+
+func TestWithEvents(t *testing.T) {
+	ctx := context.Background()
+	objects, err := manifest.ParseObjects(ctx, "apiVersion: v1\nkind: ConfigMap\nmetadata:\n  name: kept\n  namespace: config-management-system\n")
+	if err != nil {
+		t.Fatal(err)
+	}
+	liveObjects := func(context.Context, schema.GroupVersionKind, types.NamespacedName) (*unstructured.Unstructured, error) {
+		return nil, nil
+	}
+	inventory := &addonsv1alpha1.Inventory{Count: 2, Objects: []addonsv1alpha1.InventoryObject{
+		{Kind: "ConfigMap", Namespace: "config-management-system", Name: "kept"},
+		{Kind: "ConfigMap", Namespace: "config-management-system", Name: "dropped"},
+	}}
+	status := func(healthy bool, version string, inventory *addonsv1alpha1.Inventory) addonsv1alpha1.ConfigSyncStatus {
+		s := addonsv1alpha1.ConfigSyncStatus{Inventory: inventory}
+		s.Healthy = healthy
+		s.Phase = "InProgress"
+		if healthy {
+			s.Phase = "Current"
+		}
+		s.ObservedVersion = version
+		return s
+	}
+
+	rolledBack := status(false, "1.14.1", nil)
+	rolledBack.RolledBackVersion = "1.15.0"
+
+	tests := []struct {
+		name       string
+		old        addonsv1alpha1.ConfigSyncStatus
+		new        addonsv1alpha1.ConfigSyncStatus
+		err        error
+		knownError declarative.KnownErrorCode
+		events     []string
+	}{
+		{
+			name:   "install",
+			new:    status(false, "1.14.1", nil),
+			events: []string{"Normal InstallStarted Installing the addon", "Normal Installed Installed version 1.14.1"},
+		},
+		{
+			name:   "upgrade pruning an object",
+			old:    status(true, "1.14.1", inventory),
+			new:    status(false, "1.15.0", nil),
+			events: []string{"Normal VersionChanged Changed version from 1.14.1 to 1.15.0", "Normal Pruned Deleted 1 objects no longer in the manifest", "Warning Unhealthy The addon is InProgress"},
+		},
+		{
+			name:   "healthy",
+			old:    status(false, "1.14.1", nil),
+			new:    status(true, "1.14.1", nil),
+			events: []string{"Normal Healthy All the objects reached their desired state"},
+		},
+		{
+			name: "rollback",
+			old:  status(false, "1.15.0", nil),
+			new:  rolledBack,
+			events: []string{
+				"Normal VersionChanged Changed version from 1.15.0 to 1.14.1",
+				"Warning RolledBack Version 1.15.0 did not become healthy before its progress deadline, rolled back to 1.14.1",
+			},
+		},
+		{
+			name:       "apply failed",
+			old:        status(true, "1.14.1", inventory),
+			new:        status(true, "1.14.1", inventory),
+			err:        errors.New("error applying objects"),
+			knownError: declarative.KnownErrorApplyFailed,
+			events:     []string{"Warning ApplyFailed error applying objects"},
+		},
+		{
+			// The reconciler of kdp records an InternalError Event for other errors.
+			name: "other error",
+			old:  status(true, "1.14.1", inventory),
+			new:  status(true, "1.14.1", inventory),
+			err:  errors.New("error building deployment objects"),
+		},
+	}
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			recorder := record.NewFakeRecorder(10)
+			s := WithEvents(recorder, &fakeBuildStatus{status: tc.new})
+			subject := &addonsv1alpha1.ConfigSync{Status: tc.old}
+			info := &declarative.StatusInfo{Subject: subject, Manifest: objects, LiveObjects: liveObjects, Err: tc.err, KnownError: tc.knownError}
+			if err := s.BuildStatus(ctx, info); err != nil {
+				t.Fatalf("BuildStatus() error = %v", err)
+			}
+			close(recorder.Events)
+			var events []string
+			for e := range recorder.Events {
+				events = append(events, e)
+			}
+			if !reflect.DeepEqual(events, tc.events) {
+				t.Errorf("events = %q, want %q", events, tc.events)
+			}
+		})
+	}
+}
+
+func TestWithEventsRetriedInstall(t *testing.T) {
+	ctx := context.Background()
+	subject := &addonsv1alpha1.ConfigSync{}
+	installing := addonsv1alpha1.ConfigSyncStatus{}
+	installing.Phase = "InProgress"
+	var events []string
+	for _, tc := range []struct {
+		status addonsv1alpha1.ConfigSyncStatus
+		err    error
+	}{
+		// The manifest cannot be loaded twice, so the phase is not set.
+		{err: errors.New("error loading manifest")},
+		{err: errors.New("error loading manifest")},
+		{status: installing},
+		{status: installing},
+	} {
+		recorder := record.NewFakeRecorder(10)
+		s := WithEvents(recorder, &fakeBuildStatus{status: tc.status})
+		if err := s.BuildStatus(ctx, &declarative.StatusInfo{Subject: subject, Err: tc.err}); err != nil {
+			t.Fatalf("BuildStatus() error = %v", err)
+		}
+		close(recorder.Events)
+		for e := range recorder.Events {
+			events = append(events, e)
+		}
+	}
+	if want := []string{"Normal InstallStarted Installing the addon"}; !reflect.DeepEqual(events, want) {
+		t.Errorf("events = %q, want %q", events, want)
+	}
+}
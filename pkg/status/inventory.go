@@ -0,0 +1,48 @@
+package status
+
+import (
+	"sort"
+
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"sigs.k8s.io/kubebuilder-declarative-pattern/pkg/patterns/declarative"
+
+	addonsv1alpha1 "github.com/yuwenma/moss/moss/api/v1alpha1"
+)
+
+// MaxInventoryObjects caps the objects listed in the inventory, keeping the status of large packages
+// well below the size limit of an object.
+const MaxInventoryObjects = 100
+
+// Inventory summarizes the objects of info.Manifest if they were applied successfully, otherwise it
+// returns nil.
+func Inventory(info *declarative.StatusInfo) *addonsv1alpha1.Inventory {
+	if info.Err != nil || info.Manifest == nil || info.LiveObjects == nil {
+		return nil
+	}
+	inventory := &addonsv1alpha1.Inventory{Count: len(info.Manifest.Items)}
+	counts := map[schema.GroupKind]int{}
+	for _, object := range info.Manifest.Items {
+		gvk := object.GroupVersionKind()
+		counts[gvk.GroupKind()]++
+		if len(inventory.Objects) == MaxInventoryObjects {
+			inventory.Truncated = true
+			continue
+		}
+		inventory.Objects = append(inventory.Objects, addonsv1alpha1.InventoryObject{
+			Group:     gvk.Group,
+			Kind:      gvk.Kind,
+			Namespace: object.GetNamespace(),
+			Name:      object.GetName(),
+		})
+	}
+	for gk, count := range counts {
+		inventory.Kinds = append(inventory.Kinds, addonsv1alpha1.InventoryKind{Group: gk.Group, Kind: gk.Kind, Count: count})
+	}
+	sort.Slice(inventory.Kinds, func(i, j int) bool {
+		if inventory.Kinds[i].Group != inventory.Kinds[j].Group {
+			return inventory.Kinds[i].Group < inventory.Kinds[j].Group
+		}
+		return inventory.Kinds[i].Kind < inventory.Kinds[j].Kind
+	})
+	return inventory
+}
@@ -0,0 +1,55 @@
+package status
+
+import (
+	"context"
+	"fmt"
+	"reflect"
+	"strings"
+	"testing"
+
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/kubebuilder-declarative-pattern/pkg/patterns/declarative"
+	"sigs.k8s.io/kubebuilder-declarative-pattern/pkg/patterns/declarative/pkg/manifest"
+
+	addonsv1alpha1 "github.com/yuwenma/moss/moss/api/v1alpha1"
+)
+
+func TestInventory(t *testing.T) {
+	ctx := context.Background()
+	var docs []string
+	for i := 0; i < MaxInventoryObjects; i++ {
+		docs = append(docs, fmt.Sprintf("apiVersion: v1\nkind: ConfigMap\nmetadata:\n  name: cm-%d\n  namespace: argocd\n", i))
+	}
+	docs = append(docs, "apiVersion: apps/v1\nkind: Deployment\nmetadata:\n  name: argocd-server\n  namespace: argocd\n")
+	objects, err := manifest.ParseObjects(ctx, strings.Join(docs, "---\n"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	liveObjects := func(context.Context, schema.GroupVersionKind, types.NamespacedName) (*unstructured.Unstructured, error) {
+		return nil, nil
+	}
+
+	got := Inventory(&declarative.StatusInfo{Manifest: objects, LiveObjects: liveObjects})
+	if got == nil {
+		t.Fatalf("Inventory() = nil, want an inventory")
+	}
+	if got.Count != MaxInventoryObjects+1 || !got.Truncated || len(got.Objects) != MaxInventoryObjects {
+		t.Errorf("Inventory() count = %d, truncated = %v, %d objects, want %d, true, %d", got.Count, got.Truncated, len(got.Objects), MaxInventoryObjects+1, MaxInventoryObjects)
+	}
+	wantKinds := []addonsv1alpha1.InventoryKind{
+		{Kind: "ConfigMap", Count: MaxInventoryObjects},
+		{Group: "apps", Kind: "Deployment", Count: 1},
+	}
+	if !reflect.DeepEqual(got.Kinds, wantKinds) {
+		t.Errorf("Inventory().Kinds = %+v, want %+v", got.Kinds, wantKinds)
+	}
+	if want := (addonsv1alpha1.InventoryObject{Kind: "ConfigMap", Namespace: "argocd", Name: "cm-0"}); got.Objects[0] != want {
+		t.Errorf("Inventory().Objects[0] = %+v, want %+v", got.Objects[0], want)
+	}
+
+	if got := Inventory(&declarative.StatusInfo{Manifest: objects, LiveObjects: liveObjects, Err: fmt.Errorf("apply failed")}); got != nil {
+		t.Errorf("Inventory() of a failed apply = %+v, want nil", got)
+	}
+}
@@ -0,0 +1,36 @@
+package status
+
+import (
+	"sigs.k8s.io/kubebuilder-declarative-pattern/pkg/patterns/declarative"
+
+	addonsv1alpha1 "github.com/yuwenma/moss/moss/api/v1alpha1"
+	"github.com/yuwenma/moss/moss/pkg/loaders"
+)
+
+// DeployedPackage returns the package loader loaded for info.Subject if it was applied successfully,
+// otherwise nil.
+func DeployedPackage(info *declarative.StatusInfo, loader *loaders.ManifestLoader) *addonsv1alpha1.DeployedPackage {
+	if info.Err != nil || info.LiveObjects == nil {
+		return nil
+	}
+	resolution, ok := loader.Resolved(info.Subject)
+	if !ok {
+		return nil
+	}
+	return &addonsv1alpha1.DeployedPackage{
+		ObservedVersion:    resolution.Version,
+		ResolvedChannel:    resolution.Channel,
+		ManifestDigest:     resolution.Digest,
+		ObservedGeneration: info.Subject.GetGeneration(),
+	}
+}
+
+// AvailableVersion returns the newer version of the channel held back by the upgrade policy of
+// info.Subject, empty if there is none.
+func AvailableVersion(info *declarative.StatusInfo, loader *loaders.ManifestLoader) string {
+	resolution, ok := loader.Resolved(info.Subject)
+	if !ok {
+		return ""
+	}
+	return resolution.Available
+}
@@ -0,0 +1,74 @@
+package status
+
+import (
+	"fmt"
+
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"sigs.k8s.io/kubebuilder-declarative-pattern/pkg/patterns/declarative"
+
+	addonsv1alpha1 "github.com/yuwenma/moss/moss/api/v1alpha1"
+	"github.com/yuwenma/moss/moss/pkg/loaders"
+)
+
+// ConditionRolledBack is true while the requested version of an addon is rolled back to its last healthy
+// version, and false with reason RollbackBlocked if it missed its progress deadline but cannot be rolled
+// back.
+const ConditionRolledBack = "RolledBack"
+
+// ReasonRollbackBlocked is the reason of the RolledBack condition when the upgrade path of the requested
+// version does not allow rolling back.
+const ReasonRollbackBlocked = "RollbackBlocked"
+
+// Rollback records in status the health of the version loader loaded for info.Subject, and sets the
+// RolledBack condition if that version is a rollback, or missed its progress deadline but cannot be
+// rolled back. healthy is the health of the applied objects.
+func Rollback(info *declarative.StatusInfo, loader *loaders.ManifestLoader, healthy bool, status *addonsv1alpha1.RollbackStatus, conditions *[]metav1.Condition) {
+	resolution, ok := loader.Resolved(info.Subject)
+	if !ok {
+		return
+	}
+	healthy = healthy && info.Err == nil && info.LiveObjects != nil
+	switch {
+	case healthy:
+		status.LastHealthyVersion = resolution.Version
+		status.ProgressingVersion = ""
+		status.ProgressDeadline = nil
+	case resolution.RollbackBlocked != "":
+		// The progress deadline stays exceeded until the version becomes healthy or another version is
+		// requested.
+	case !resolution.ProgressDeadline.IsZero():
+		status.ProgressingVersion = resolution.Version
+		status.ProgressDeadline = &metav1.Time{Time: resolution.ProgressDeadline}
+	default:
+		status.ProgressingVersion = ""
+		status.ProgressDeadline = nil
+	}
+
+	if resolution.RollbackBlocked != "" && !healthy {
+		status.RolledBackVersion = ""
+		meta.SetStatusCondition(conditions, metav1.Condition{
+			Type:   ConditionRolledBack,
+			Status: metav1.ConditionFalse,
+			Reason: ReasonRollbackBlocked,
+			Message: fmt.Sprintf("Version %s did not become healthy before its progress deadline, but is not rolled back: %s",
+				resolution.Version, resolution.RollbackBlocked),
+			ObservedGeneration: info.Subject.GetGeneration(),
+		})
+		return
+	}
+	if resolution.RolledBackVersion == "" {
+		status.RolledBackVersion = ""
+		meta.RemoveStatusCondition(conditions, ConditionRolledBack)
+		return
+	}
+	status.RolledBackVersion = resolution.RolledBackVersion
+	meta.SetStatusCondition(conditions, metav1.Condition{
+		Type:   ConditionRolledBack,
+		Status: metav1.ConditionTrue,
+		Reason: "ProgressDeadlineExceeded",
+		Message: fmt.Sprintf("Version %s did not become healthy before its progress deadline, version %s is applied instead",
+			resolution.RolledBackVersion, resolution.Version),
+		ObservedGeneration: info.Subject.GetGeneration(),
+	})
+}
@@ -0,0 +1,32 @@
+// Package testutil holds the helpers shared by the controller tests.
+package testutil
+
+import (
+	"context"
+	"os"
+	"testing"
+
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/kubebuilder-declarative-pattern/pkg/patterns/declarative/pkg/manifest"
+)
+
+// CreateObjects creates the objects of the manifest at path, e.g. the objects shared by all the test
+// inputs of a golden test. They are created as unstructured objects because the mock apiserver does not
+// accept the protobuf encoding used for typed core objects.
+func CreateObjects(t *testing.T, c client.Client, path string) {
+	t.Helper()
+	ctx := context.Background()
+	b, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatalf("reading %s: %v", path, err)
+	}
+	objects, err := manifest.ParseObjects(ctx, string(b))
+	if err != nil {
+		t.Fatalf("parsing %s: %v", path, err)
+	}
+	for _, object := range objects.Items {
+		if err := c.Create(ctx, object.UnstructuredObject()); err != nil {
+			t.Fatalf("creating %s/%s: %v", object.Kind, object.GetName(), err)
+		}
+	}
+}
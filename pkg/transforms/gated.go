@@ -0,0 +1,33 @@
+package transforms
+
+import (
+	"context"
+
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/kubebuilder-declarative-pattern/pkg/patterns/declarative/pkg/manifest"
+
+	mossstatus "github.com/yuwenma/moss/moss/pkg/status"
+)
+
+// AddGated adds objects that depend on others being ready first, e.g. custom resources served by a CRD
+// installed by an operator of the manifest. Until ready, only the objects that already exist are added,
+// so that the applier does not prune them while their prerequisites are being upgraded.
+func AddGated(ctx context.Context, c client.Reader, objects *manifest.Objects, ready bool, gated []*unstructured.Unstructured) error {
+	for _, u := range gated {
+		if !ready {
+			exists, err := mossstatus.Exists(ctx, c, u.GroupVersionKind(), types.NamespacedName{Namespace: u.GetNamespace(), Name: u.GetName()})
+			if err != nil {
+				return err
+			}
+			if !exists {
+				continue
+			}
+		}
+		if err := AddObject(objects, u); err != nil {
+			return err
+		}
+	}
+	return nil
+}
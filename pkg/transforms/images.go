@@ -0,0 +1,73 @@
+package transforms
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"sigs.k8s.io/kubebuilder-declarative-pattern/pkg/patterns/declarative"
+	"sigs.k8s.io/kubebuilder-declarative-pattern/pkg/patterns/declarative/pkg/manifest"
+)
+
+// ImageRegistry is the registry the images of the packages are pulled from instead of their upstream
+// registry, e.g. registry.internal:5000/mirror for air-gapped clusters. Images are not rewritten if empty.
+var ImageRegistry string
+
+// workloadKinds are the kinds whose images are rewritten by declarative.PrivateRegistryTransform.
+var workloadKinds = map[string]bool{"Deployment": true, "DaemonSet": true, "StatefulSet": true, "Job": true, "CronJob": true}
+
+// Images returns the images of the containers the manifest pulls from ImageRegistry once rewritten, sorted
+// and without duplicates.
+func Images(objects *manifest.Objects) ([]string, error) {
+	seen := map[string]bool{}
+	for _, object := range objects.Items {
+		if !workloadKinds[object.Kind] {
+			continue
+		}
+		if err := object.MutateContainers(func(container map[string]interface{}) error {
+			if image, _, _ := unstructured.NestedString(container, "image"); image != "" {
+				seen[image] = true
+			}
+			return nil
+		}); err != nil {
+			return nil, fmt.Errorf("error reading images of %s/%s: %w", object.Kind, object.GetName(), err)
+		}
+	}
+	images := make([]string, 0, len(seen))
+	for image := range seen {
+		images = append(images, image)
+	}
+	sort.Strings(images)
+	return images, nil
+}
+
+// RewriteImages is an ObjectTransform pulling the images of the containers from ImageRegistry. It must run
+// before the objects of the addon spec are added, as only the images of the packages are bundled.
+func RewriteImages(ctx context.Context, o declarative.DeclarativeObject, objects *manifest.Objects) error {
+	return declarative.PrivateRegistryTransform(ImageRegistry, "", func(registry, image string) string {
+		return RewriteImage(image, registry)
+	})(ctx, o, objects)
+}
+
+// RewriteImage replaces the registry of image with registry, keeping its repository path, tag and
+// digest, e.g. quay.io/argoproj/argocd:v2.5.11 becomes registry.internal/argoproj/argocd:v2.5.11.
+// Docker Hub images are normalized first, redis:7.0.7 becoming registry.internal/library/redis:7.0.7.
+func RewriteImage(image, registry string) string {
+	_, repository := splitImageRegistry(image)
+	return strings.TrimSuffix(registry, "/") + "/" + repository
+}
+
+// splitImageRegistry splits image into its registry and its repository path. The first path component
+// is a registry if it contains a dot or a port, or is localhost, as for the docker CLI.
+func splitImageRegistry(image string) (string, string) {
+	first, rest, ok := strings.Cut(image, "/")
+	if ok && (strings.ContainsAny(first, ".:") || first == "localhost") {
+		return first, rest
+	}
+	if !ok {
+		return "docker.io", "library/" + image
+	}
+	return "docker.io", image
+}
@@ -0,0 +1,91 @@
+package transforms
+
+import (
+	"context"
+	"reflect"
+	"strings"
+	"testing"
+
+	"sigs.k8s.io/kubebuilder-declarative-pattern/pkg/patterns/declarative/pkg/manifest"
+)
+
+func TestRewriteImage(t *testing.T) {
+	tests := []struct {
+		image string
+		want  string
+	}{
+		{image: "quay.io/argoproj/argocd:v2.5.11", want: "registry.internal:5000/mirror/argoproj/argocd:v2.5.11"},
+		{image: "redis:7.0.7-alpine", want: "registry.internal:5000/mirror/library/redis:7.0.7-alpine"},
+		{image: "bitnami/redis:7.0", want: "registry.internal:5000/mirror/bitnami/redis:7.0"},
+		{image: "localhost/dex@sha256:0123", want: "registry.internal:5000/mirror/dex@sha256:0123"},
+		{image: "localhost:5000/dex:v2", want: "registry.internal:5000/mirror/dex:v2"},
+	}
+	for _, tc := range tests {
+		if got := RewriteImage(tc.image, "registry.internal:5000/mirror/"); got != tc.want {
+			t.Errorf("RewriteImage(%q) = %q, want %q", tc.image, got, tc.want)
+		}
+	}
+}
+
+func TestRewriteImages(t *testing.T) {
+	ctx := context.Background()
+	objects, err := manifest.ParseObjects(ctx, deployment+`---
+apiVersion: batch/v1
+kind: Job
+metadata:
+  name: cleanup
+spec:
+  template:
+    spec:
+      initContainers:
+      - name: wait
+        image: busybox:1.36
+      containers:
+      - name: cleanup
+        image: quay.io/argoproj/argocd:v2.5.11
+---
+apiVersion: v1
+kind: ConfigMap
+metadata:
+  name: settings
+data:
+  image: redis:7.0.7-alpine
+`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	images, err := Images(objects)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := []string{"busybox:1.36", "operator:v1", "quay.io/argoproj/argocd:v2.5.11"}; !reflect.DeepEqual(images, want) {
+		t.Errorf("Images() = %v, want %v", images, want)
+	}
+
+	defer func(registry string) { ImageRegistry = registry }(ImageRegistry)
+	ImageRegistry = "registry.internal"
+	if err := RewriteImages(ctx, nil, objects); err != nil {
+		t.Fatalf("RewriteImages() error = %v", err)
+	}
+	images, err = Images(objects)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []string{"registry.internal/argoproj/argocd:v2.5.11", "registry.internal/library/busybox:1.36", "registry.internal/library/operator:v1"}
+	if !reflect.DeepEqual(images, want) {
+		t.Errorf("Images() after RewriteImages() = %v, want %v", images, want)
+	}
+	// The cached JSON applied to the cluster is rewritten too.
+	for _, object := range objects.Items {
+		b, err := object.JSON()
+		if err != nil {
+			t.Fatal(err)
+		}
+		if object.Kind == "ConfigMap" {
+			continue
+		}
+		if s := string(b); !strings.Contains(s, "registry.internal/") {
+			t.Errorf("JSON of %s/%s = %s, want rewritten images", object.Kind, object.GetName(), s)
+		}
+	}
+}
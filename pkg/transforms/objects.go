@@ -0,0 +1,53 @@
+package transforms
+
+import (
+	"fmt"
+
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	"sigs.k8s.io/kubebuilder-declarative-pattern/pkg/patterns/declarative/pkg/manifest"
+)
+
+// FindObject returns the object of the given kind and name, or an error if the manifest does not contain it.
+func FindObject(objects *manifest.Objects, kind, name string) (*manifest.Object, error) {
+	for _, object := range objects.Items {
+		if object.Kind == kind && object.GetName() == name {
+			return object, nil
+		}
+	}
+	return nil, fmt.Errorf("%s/%s not found in manifest", kind, name)
+}
+
+// SetConfigMapData sets the keys of data on the named ConfigMap, keeping the keys shipped in the manifest.
+func SetConfigMapData(objects *manifest.Objects, name string, data map[string]string) error {
+	if len(data) == 0 {
+		return nil
+	}
+	object, err := FindObject(objects, "ConfigMap", name)
+	if err != nil {
+		return err
+	}
+	for k, v := range data {
+		if err := object.SetNestedField(v, "data", k); err != nil {
+			return fmt.Errorf("error setting %s in ConfigMap %s: %w", k, name, err)
+		}
+	}
+	return nil
+}
+
+// AddObject appends a typed or unstructured object generated by the controller to the manifest.
+func AddObject(objects *manifest.Objects, object runtime.Object) error {
+	u, err := runtime.DefaultUnstructuredConverter.ToUnstructured(object)
+	if err != nil {
+		return fmt.Errorf("error converting %T: %w", object, err)
+	}
+	// Typed objects serialize an empty creationTimestamp as null and an empty status.
+	unstructured.RemoveNestedField(u, "metadata", "creationTimestamp")
+	unstructured.RemoveNestedField(u, "status")
+	o, err := manifest.NewObject(&unstructured.Unstructured{Object: u})
+	if err != nil {
+		return fmt.Errorf("error building %T: %w", object, err)
+	}
+	objects.Items = append(objects.Items, o)
+	return nil
+}
@@ -0,0 +1,151 @@
+package transforms
+
+import (
+	"fmt"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"sigs.k8s.io/kubebuilder-declarative-pattern/pkg/patterns/declarative/pkg/manifest"
+
+	addonsv1alpha1 "github.com/yuwenma/moss/moss/api/v1alpha1"
+)
+
+// Workload identifies the Deployment or StatefulSet of an addon component in a package manifest.
+type Workload struct {
+	Kind string
+	Name string
+	// Container is the main container of the workload, the one WorkloadSpec.Resources applies to.
+	Container string
+}
+
+func (w Workload) String() string {
+	return w.Kind + "/" + w.Name
+}
+
+// Find returns the workload object, or an error if the manifest does not contain it.
+func (w Workload) Find(objects *manifest.Objects) (*manifest.Object, error) {
+	return FindObject(objects, w.Kind, w.Name)
+}
+
+// ApplyWorkloadSpec writes the fields set in spec into the workload. It fails when the workload or its
+// main container is missing from the manifest, so that a component renamed upstream is reported
+// rather than silently left unconfigured.
+func ApplyWorkloadSpec(objects *manifest.Objects, w Workload, spec *addonsv1alpha1.WorkloadSpec) error {
+	if spec == nil {
+		return nil
+	}
+	if err := ValidateWorkloadSpec(spec); err != nil {
+		return fmt.Errorf("invalid settings for %s: %w", w, err)
+	}
+	object, err := w.Find(objects)
+	if err != nil {
+		return err
+	}
+
+	if spec.Replicas != nil {
+		if err := object.SetNestedField(int64(*spec.Replicas), "spec", "replicas"); err != nil {
+			return fmt.Errorf("error setting replicas of %s: %w", w, err)
+		}
+	}
+
+	if spec.Resources != nil {
+		resources, err := runtime.DefaultUnstructuredConverter.ToUnstructured(spec.Resources)
+		if err != nil {
+			return fmt.Errorf("error converting resources of %s: %w", w, err)
+		}
+		if err := mutateContainer(object, w, func(container map[string]interface{}) {
+			container["resources"] = resources
+		}); err != nil {
+			return err
+		}
+	}
+
+	return object.MutatePodSpec(func(podSpec map[string]interface{}) error {
+		if spec.NodeSelector != nil {
+			nodeSelector := make(map[string]interface{}, len(spec.NodeSelector))
+			for k, v := range spec.NodeSelector {
+				nodeSelector[k] = v
+			}
+			podSpec["nodeSelector"] = nodeSelector
+		}
+		if spec.Tolerations != nil {
+			var tolerations []interface{}
+			for i := range spec.Tolerations {
+				toleration, err := runtime.DefaultUnstructuredConverter.ToUnstructured(&spec.Tolerations[i])
+				if err != nil {
+					return fmt.Errorf("error converting tolerations of %s: %w", w, err)
+				}
+				tolerations = append(tolerations, toleration)
+			}
+			podSpec["tolerations"] = tolerations
+		}
+		if spec.Affinity != nil {
+			affinity, err := runtime.DefaultUnstructuredConverter.ToUnstructured(spec.Affinity)
+			if err != nil {
+				return fmt.Errorf("error converting affinity of %s: %w", w, err)
+			}
+			podSpec["affinity"] = affinity
+		}
+		if spec.PriorityClassName != "" {
+			podSpec["priorityClassName"] = spec.PriorityClassName
+		}
+		return nil
+	})
+}
+
+// ValidateWorkloadSpec checks the constraints the apiserver would otherwise only report when applying the workload.
+func ValidateWorkloadSpec(spec *addonsv1alpha1.WorkloadSpec) error {
+	if spec.Replicas != nil && *spec.Replicas < 0 {
+		return fmt.Errorf("replicas must not be negative, got %d", *spec.Replicas)
+	}
+	if spec.Resources != nil {
+		for name, request := range spec.Resources.Requests {
+			limit, ok := spec.Resources.Limits[name]
+			if ok && request.Cmp(limit) > 0 {
+				return fmt.Errorf("resources.requests.%s (%s) must not exceed resources.limits.%s (%s)", name, request.String(), name, limit.String())
+			}
+		}
+	}
+	for _, toleration := range spec.Tolerations {
+		if toleration.Operator == corev1.TolerationOpExists && toleration.Value != "" {
+			return fmt.Errorf("toleration for key %q must not set a value with operator Exists", toleration.Key)
+		}
+	}
+	return nil
+}
+
+// SetEnv sets the environment variable on the main container of the workload, replacing any existing value.
+func SetEnv(objects *manifest.Objects, w Workload, name, value string) error {
+	object, err := w.Find(objects)
+	if err != nil {
+		return err
+	}
+	return mutateContainer(object, w, func(container map[string]interface{}) {
+		env, _ := container["env"].([]interface{})
+		for _, e := range env {
+			if e, ok := e.(map[string]interface{}); ok && e["name"] == name {
+				delete(e, "valueFrom")
+				e["value"] = value
+				return
+			}
+		}
+		container["env"] = append(env, map[string]interface{}{"name": name, "value": value})
+	})
+}
+
+func mutateContainer(object *manifest.Object, w Workload, fn func(container map[string]interface{})) error {
+	found := false
+	if err := object.MutateContainers(func(container map[string]interface{}) error {
+		if container["name"] == w.Container {
+			found = true
+			fn(container)
+		}
+		return nil
+	}); err != nil {
+		return fmt.Errorf("error reading containers of %s: %w", w, err)
+	}
+	if !found {
+		return fmt.Errorf("container %q not found in %s", w.Container, w)
+	}
+	return nil
+}
@@ -0,0 +1,72 @@
+package transforms
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+	"sigs.k8s.io/kubebuilder-declarative-pattern/pkg/patterns/declarative/pkg/manifest"
+
+	addonsv1alpha1 "github.com/yuwenma/moss/moss/api/v1alpha1"
+)
+
+const deployment = `
+apiVersion: apps/v1
+kind: Deployment
+metadata:
+  name: operator
+spec:
+  template:
+    spec:
+      containers:
+      - name: manager
+        image: operator:v1
+`
+
+func TestApplyWorkloadSpecErrors(t *testing.T) {
+	replicas := int32(2)
+	tests := []struct {
+		name     string
+		workload Workload
+		spec     *addonsv1alpha1.WorkloadSpec
+		want     string
+	}{
+		{
+			name:     "missing workload",
+			workload: Workload{Kind: "Deployment", Name: "renamed", Container: "manager"},
+			spec:     &addonsv1alpha1.WorkloadSpec{Replicas: &replicas},
+			want:     "Deployment/renamed not found in manifest",
+		},
+		{
+			name:     "missing container",
+			workload: Workload{Kind: "Deployment", Name: "operator", Container: "renamed"},
+			spec: &addonsv1alpha1.WorkloadSpec{Resources: &corev1.ResourceRequirements{
+				Requests: corev1.ResourceList{corev1.ResourceCPU: resource.MustParse("1")},
+			}},
+			want: `container "renamed" not found in Deployment/operator`,
+		},
+		{
+			name:     "requests above limits",
+			workload: Workload{Kind: "Deployment", Name: "operator", Container: "manager"},
+			spec: &addonsv1alpha1.WorkloadSpec{Resources: &corev1.ResourceRequirements{
+				Requests: corev1.ResourceList{corev1.ResourceMemory: resource.MustParse("2Gi")},
+				Limits:   corev1.ResourceList{corev1.ResourceMemory: resource.MustParse("1Gi")},
+			}},
+			want: "resources.requests.memory (2Gi) must not exceed resources.limits.memory (1Gi)",
+		},
+	}
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			objects, err := manifest.ParseObjects(context.Background(), deployment)
+			if err != nil {
+				t.Fatalf("parsing manifest: %v", err)
+			}
+			err = ApplyWorkloadSpec(objects, tc.workload, tc.spec)
+			if err == nil || !strings.Contains(err.Error(), tc.want) {
+				t.Errorf("ApplyWorkloadSpec() error = %v, want error containing %q", err, tc.want)
+			}
+		})
+	}
+}
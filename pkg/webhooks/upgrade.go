@@ -0,0 +1,72 @@
+// Package webhooks holds the admission webhooks shared by the addons.
+package webhooks
+
+import (
+	"context"
+
+	"k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/meta"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/util/validation/field"
+	"sigs.k8s.io/controller-runtime/pkg/webhook/admission"
+	"sigs.k8s.io/kubebuilder-declarative-pattern/pkg/patterns/addon/pkg/utils"
+
+	"github.com/yuwenma/moss/moss/pkg/loaders"
+)
+
+// UpgradeValidator rejects the changes of spec.version which the upgrade paths of the addon package do
+// not allow. The version is changed from the deployed version, or from the previous spec.version while
+// nothing is deployed yet.
+type UpgradeValidator struct {
+	Loader *loaders.ManifestLoader
+}
+
+var _ admission.CustomValidator = &UpgradeValidator{}
+
+func (v *UpgradeValidator) ValidateCreate(ctx context.Context, obj runtime.Object) error {
+	return nil
+}
+
+func (v *UpgradeValidator) ValidateUpdate(ctx context.Context, oldObj, newObj runtime.Object) error {
+	newSpec, err := utils.GetCommonSpec(newObj)
+	if err != nil {
+		return err
+	}
+	if newSpec.Version == "" {
+		return nil
+	}
+	oldSpec, err := utils.GetCommonSpec(oldObj)
+	if err != nil {
+		return err
+	}
+	from := oldSpec.Version
+	if o, ok := oldObj.(loaders.Upgradable); ok {
+		if deployed := o.GetDeployedPackage().ObservedVersion; deployed != "" {
+			from = deployed
+		}
+	}
+	if from == newSpec.Version {
+		return nil
+	}
+
+	packageName, err := utils.GetCommonName(newObj)
+	if err != nil {
+		return err
+	}
+	pathErr := v.Loader.CheckUpgradePath(ctx, packageName, from, newSpec.Version)
+	if pathErr == nil {
+		return nil
+	}
+	accessor, err := meta.Accessor(newObj)
+	if err != nil {
+		return err
+	}
+	gvk := newObj.GetObjectKind().GroupVersionKind()
+	return errors.NewInvalid(gvk.GroupKind(), accessor.GetName(), field.ErrorList{
+		field.Invalid(field.NewPath("spec", "version"), newSpec.Version, pathErr.Error()),
+	})
+}
+
+func (v *UpgradeValidator) ValidateDelete(ctx context.Context, obj runtime.Object) error {
+	return nil
+}
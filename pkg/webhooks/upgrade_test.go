@@ -0,0 +1,62 @@
+package webhooks
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"k8s.io/apimachinery/pkg/api/errors"
+
+	addonsv1alpha1 "github.com/yuwenma/moss/moss/api/v1alpha1"
+	"github.com/yuwenma/moss/moss/pkg/loaders"
+)
+
+func TestUpgradeValidator(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "packages", "configsync", "1.16.0", "metadata", "upgrade.yaml")
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(path, []byte("from:\n- \">=1.14.0 <1.16.0\"\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	loader, err := loaders.NewManifestLoader(dir, nil, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	v := &UpgradeValidator{Loader: loader}
+
+	configSync := func(version, deployed string) *addonsv1alpha1.ConfigSync {
+		o := &addonsv1alpha1.ConfigSync{}
+		o.SetGroupVersionKind(addonsv1alpha1.GroupVersion.WithKind("ConfigSync"))
+		o.Name = "configsync-sample"
+		o.Spec.Version = version
+		o.Status.ObservedVersion = deployed
+		return o
+	}
+	tests := []struct {
+		name    string
+		old     *addonsv1alpha1.ConfigSync
+		new     *addonsv1alpha1.ConfigSync
+		wantErr bool
+	}{
+		{name: "allowed upgrade", old: configSync("1.14.1", "1.14.1"), new: configSync("1.16.0", "1.14.1")},
+		{name: "skipped versions", old: configSync("1.13.0", "1.13.0"), new: configSync("1.16.0", "1.13.0"), wantErr: true},
+		{name: "from deployed version", old: configSync("1.14.1", "1.13.0"), new: configSync("1.16.0", "1.13.0"), wantErr: true},
+		{name: "not deployed yet", old: configSync("1.14.1", ""), new: configSync("1.16.0", "")},
+		{name: "from channel", old: configSync("", "1.13.0"), new: configSync("1.16.0", "1.13.0"), wantErr: true},
+		{name: "to channel", old: configSync("1.14.1", "1.14.1"), new: configSync("", "1.14.1")},
+	}
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			err := v.ValidateUpdate(context.Background(), tc.old, tc.new)
+			if (err != nil) != tc.wantErr {
+				t.Fatalf("ValidateUpdate() error = %v, want error %t", err, tc.wantErr)
+			}
+			if err != nil && !errors.IsInvalid(err) {
+				t.Errorf("ValidateUpdate() error = %v, want an Invalid error", err)
+			}
+		})
+	}
+}